@@ -0,0 +1,102 @@
+// Package clock is the source of time of the game. Everything that depends
+// on the time asks this package instead of calling time.Now and time.Sleep
+// directly, so the time could be faked in tests and simulations.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and lets goroutines wait for it.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) *Timer
+	Go(f func())
+}
+
+// Timer sends the time on C once it expires, just like time.Timer.
+type Timer struct {
+	C    <-chan time.Time
+	stop func() bool
+}
+
+// Stops the timer. Returns false if it has already expired or been stopped.
+// Timers used in a select must be stopped, or a fake clock keeps waiting
+// for them.
+func (t *Timer) Stop() bool {
+	return t.stop()
+}
+
+var (
+	mutex   sync.RWMutex
+	current Clock = realClock{}
+)
+
+// Returns the clock in use.
+func Current() Clock {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Replaces the clock in use and returns the previous one.
+func Set(c Clock) Clock {
+	mutex.Lock()
+	defer mutex.Unlock()
+	previous := current
+	current = c
+	return previous
+}
+
+// Returns the current time.
+func Now() time.Time {
+	return Current().Now()
+}
+
+// Returns the current Unix time in milliseconds, like Mission.StartTime.
+func NowMs() int64 {
+	return Now().UnixNano() / 1e6
+}
+
+// Creates a new timer, which expires after d.
+func NewTimer(d time.Duration) *Timer {
+	return Current().NewTimer(d)
+}
+
+// Pauses the current goroutine for at least d.
+func Sleep(d time.Duration) {
+	SleepOn(Current(), d)
+}
+
+// Pauses the current goroutine for at least d on the given clock.
+func SleepOn(c Clock, d time.Duration) {
+	<-c.NewTimer(d).C
+}
+
+// Runs f in a new goroutine of the current clock and gives it that clock.
+// Goroutines which wait for the clock, like the missionaries, have to be
+// started with it, so a fake clock knows about all of them. They should
+// also wait only on the clock they are given, even if the current one is
+// replaced in the meantime, or a fake clock would wait for goroutines it
+// doesn't know about.
+func Go(f func(c Clock)) {
+	c := Current()
+	c.Go(func() { f(c) })
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) *Timer {
+	timer := time.NewTimer(d)
+	return &Timer{C: timer.C, stop: timer.Stop}
+}
+
+func (realClock) Go(f func()) {
+	go f()
+}
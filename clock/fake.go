@@ -0,0 +1,164 @@
+package clock
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Fake is a clock which moves only when told to. It keeps track of the
+// goroutines started with Go and the timers they wait for, so the time
+// could be moved from one timer to the next only once everyone is waiting.
+// That makes the order of events exactly the same on every run.
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	running int
+	timers  []*fakeTimer
+	created int
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	order    int
+	c        chan time.Time
+}
+
+// Creates a fake clock stopped at the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) *Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	timer := &fakeTimer{
+		deadline: f.now.Add(d),
+		order:    f.created,
+		c:        make(chan time.Time, 1),
+	}
+	f.created++
+	f.timers = append(f.timers, timer)
+	return &Timer{C: timer.c, stop: func() bool { return f.stop(timer) }}
+}
+
+func (f *Fake) Go(fn func()) {
+	f.mutex.Lock()
+	f.running++
+	f.mutex.Unlock()
+
+	go func() {
+		defer func() {
+			f.mutex.Lock()
+			f.running--
+			f.mutex.Unlock()
+		}()
+		fn()
+	}()
+}
+
+func (f *Fake) stop(timer *fakeTimer) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i, t := range f.timers {
+		if t == timer {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether every goroutine started with Go waits for a timer.
+func (f *Fake) Idle() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.running <= len(f.timers)
+}
+
+// Waits until every goroutine started with Go waits for a timer or
+// returns an error if that doesn't happen within the given real time.
+func (f *Fake) WaitIdle(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for !f.Idle() {
+		if time.Now().After(deadline) {
+			return errors.New("Goroutines are still busy")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+// Returns when the next timer expires. The second result is false if
+// nobody waits.
+func (f *Fake) Next() (time.Time, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	next := f.next()
+	if next < 0 {
+		return time.Time{}, false
+	}
+	return f.timers[next].deadline, true
+}
+
+// Moves the time to the next timer and fires it. Timers expiring at the
+// same time are fired one by one, in the order they were created.
+// Returns false if there are no timers.
+func (f *Fake) Step() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	next := f.next()
+	if next < 0 {
+		return false
+	}
+	timer := f.timers[next]
+	f.timers = append(f.timers[:next], f.timers[next+1:]...)
+	if timer.deadline.After(f.now) {
+		f.now = timer.deadline
+	}
+	timer.c <- f.now
+	return true
+}
+
+// Moves the time forward, firing all timers expiring until then.
+// Unlike Step it doesn't wait for anyone, so it's meant for code
+// which doesn't start goroutines.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	target := f.now.Add(d)
+	f.mutex.Unlock()
+
+	for {
+		next, ok := f.Next()
+		if !ok || next.After(target) {
+			break
+		}
+		f.Step()
+	}
+
+	f.mutex.Lock()
+	f.now = target
+	f.mutex.Unlock()
+}
+
+// Returns the index of the timer expiring first or -1 if there are none.
+func (f *Fake) next() int {
+	next := -1
+	for i, timer := range f.timers {
+		if next < 0 || timer.deadline.Before(f.timers[next].deadline) ||
+			timer.deadline.Equal(f.timers[next].deadline) && timer.order < f.timers[next].order {
+			next = i
+		}
+	}
+	return next
+}
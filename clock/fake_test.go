@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2014, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFakeStep(t *testing.T) {
+	fake := NewFake(epoch)
+	late := fake.NewTimer(2 * time.Second)
+	early := fake.NewTimer(time.Second)
+	same := fake.NewTimer(time.Second)
+
+	if !fake.Step() {
+		t.Fatal("No timer has been fired")
+	}
+	select {
+	case now := <-early.C:
+		if !now.Equal(epoch.Add(time.Second)) {
+			t.Errorf("The timer has been fired at %s", now)
+		}
+	default:
+		t.Error("The earliest timer is not fired first")
+	}
+
+	fake.Step()
+	select {
+	case <-same.C:
+	default:
+		t.Error("Timers expiring at the same time are not fired in order")
+	}
+
+	if !late.Stop() {
+		t.Error("The pending timer could not be stopped")
+	}
+	if fake.Step() {
+		t.Error("A stopped timer has been fired")
+	}
+	if !fake.Now().Equal(epoch.Add(time.Second)) {
+		t.Errorf("The time has moved to %s", fake.Now())
+	}
+}
+
+func TestFakeGo(t *testing.T) {
+	fake := NewFake(epoch)
+	previous := Set(fake)
+	defer Set(previous)
+
+	woke := make(chan time.Time, 1)
+	Go(func(c Clock) {
+		woke <- <-c.NewTimer(time.Minute).C
+	})
+
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if next, ok := fake.Next(); !ok || !next.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("The next timer expires at %s", next)
+	}
+
+	fake.Advance(time.Hour)
+	if now := <-woke; !now.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("The goroutine woke up at %s", now)
+	}
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Error(err)
+	}
+	if !fake.Now().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("The time has moved to %s", fake.Now())
+	}
+}
@@ -0,0 +1,79 @@
+// Command loadtest opens many simulated players against a running server
+// and reports how fast it answers them.
+//
+// Every player logs in (registering through setup_parameters if needed),
+// then scrolls around its home planet with scope_of_view and every now
+// and then launches a random mission to one of the planets it sees. At
+// the end the latency percentiles of every response command are printed
+// together with all errors.
+//
+//	$ go run ./cmd/loadtest -players 200 -duration 5m
+//
+// Point it at a server with a database you don't care about, because all
+// simulated players stay in the universe.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	address  = flag.String("url", "ws://localhost:7000/universe", "websocket address of the server")
+	origin   = flag.String("origin", "http://localhost/", "origin of the websocket connections")
+	players  = flag.Int("players", 10, "number of simulated players")
+	duration = flag.Duration("duration", time.Minute, "how long every player plays")
+	think    = flag.Duration("think", time.Second, "pause between two actions of a player")
+	missions = flag.Float64("missions", 0.3, "chance of launching a mission after every scroll")
+	ramp     = flag.Duration("ramp", 50*time.Millisecond, "pause between connecting two players")
+	tag      = flag.String("tag", "loadtest", "goes in the middle of the usernames of the simulated players")
+	timeout  = flag.Duration("timeout", 10*time.Second, "how long to wait for a response")
+	seed     = flag.Int64("seed", 0, "seed of the random actions (default: current time)")
+)
+
+func main() {
+	flag.Parse()
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	results := newStats()
+	started := time.Now()
+	var wg sync.WaitGroup
+
+	for n := 0; n < *players; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			p := &player{
+				username: username(n),
+				stats:    results,
+				random:   rand.New(rand.NewSource(*seed + int64(n))),
+			}
+			if err := p.play(time.Now().Add(*duration)); err != nil {
+				results.fail("connection", err)
+			}
+		}(n)
+		time.Sleep(*ramp)
+	}
+
+	wg.Wait()
+	results.report(os.Stdout, time.Since(started))
+}
+
+// Returns the username of the n-th player: three letters, the tag and a
+// number, e.g. abcloadtest0. The server names suns after the first three
+// letters of the username, so they are spread over the letters in order
+// to keep the solar systems of the players apart.
+func username(n int) string {
+	letters := make([]byte, 3)
+	for i := range letters {
+		letters[len(letters)-1-i] = byte('a' + n%26)
+		n /= 26
+	}
+	return fmt.Sprintf("%s%s%d", letters, *tag, n)
+}
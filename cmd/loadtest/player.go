@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+	"golang.org/x/net/websocket"
+
+	"warcluster/server"
+	"warcluster/server/response"
+)
+
+const scrollRadius = 20000 // how far from home the players scroll
+
+var (
+	resolution   = []uint64{1920, 1080}
+	missionTypes = []string{"Attack", "Supply", "Spy"}
+)
+
+// A simulated player talking to the server over its own websocket.
+type player struct {
+	username string
+	ws       *websocket.Conn
+	stats    *stats
+	random   *rand.Rand
+	home     *vec2d.Vector
+	own      []string
+	visible  []string
+}
+
+// Logs in and plays until the given time.
+func (p *player) play(until time.Time) error {
+	var err error
+	if p.ws, err = websocket.Dial(*address, "", *origin); err != nil {
+		return err
+	}
+	defer p.ws.Close()
+
+	if err := p.login(); err != nil {
+		return err
+	}
+	for time.Now().Before(until) {
+		if err := p.scroll(); err != nil {
+			p.stats.fail("scope_of_view", err)
+		}
+		if p.random.Float64() < *missions {
+			if err := p.launchMission(); err != nil {
+				p.stats.fail("start_mission", err)
+			}
+		}
+		time.Sleep(*think)
+	}
+	return nil
+}
+
+// Logs in, registering the player first if the server asks for it.
+func (p *player) login() error {
+	start := time.Now()
+	err := websocket.JSON.Send(p.ws, &server.Request{
+		Command:   "login",
+		Username:  p.username,
+		TwitterID: p.username,
+	})
+	if err != nil {
+		return err
+	}
+
+	races := 1
+	for {
+		message, command, err := p.receive()
+		if err != nil {
+			return err
+		}
+
+		switch command {
+		case "server_params":
+			var params response.ServerParams
+			if json.Unmarshal(message, &params) == nil && len(params.Races) > 0 {
+				races = len(params.Races)
+			}
+		case "request_setup_params":
+			p.stats.record(command, time.Since(start))
+			err := websocket.JSON.Send(p.ws, &server.Request{
+				Command: "setup_parameters",
+				Race:    uint8(p.random.Intn(races)),
+			})
+			if err != nil {
+				return err
+			}
+		case "login_success":
+			p.stats.record(command, time.Since(start))
+			var success response.LoginSuccess
+			if err := json.Unmarshal(message, &success); err != nil {
+				return err
+			}
+			p.home = success.HomePlanet.Position
+			p.own = []string{fmt.Sprintf("planet.%s", success.HomePlanet.Name)}
+			return nil
+		case "login_failed":
+			return errors.New("Login failed")
+		default:
+			p.stats.push(command)
+		}
+	}
+}
+
+// Looks at a random part of the universe around the home planet.
+func (p *player) scroll() error {
+	position := vec2d.New(
+		p.home.X+float64(p.random.Intn(2*scrollRadius)-scrollRadius),
+		p.home.Y+float64(p.random.Intn(2*scrollRadius)-scrollRadius),
+	)
+	message, err := p.request(&server.Request{
+		Command:    "scope_of_view",
+		Position:   position,
+		Resolution: resolution,
+	}, "scope_of_view_result")
+	if err != nil {
+		return err
+	}
+
+	var view response.ScopeOfView
+	if err := json.Unmarshal(message, &view); err != nil {
+		return err
+	}
+	p.visible = p.visible[:0]
+	for key, planet := range view.Planets {
+		if planet.Owner == p.username && !contains(p.own, key) {
+			p.own = append(p.own, key)
+		}
+		p.visible = append(p.visible, key)
+	}
+	return nil
+}
+
+// Sends a mission of random type and fleet from one of the player's
+// planets to one of the planets seen on the last scroll.
+func (p *player) launchMission() error {
+	if len(p.visible) == 0 {
+		return nil
+	}
+	source := p.own[p.random.Intn(len(p.own))]
+	target := p.visible[p.random.Intn(len(p.visible))]
+	if source == target {
+		return nil
+	}
+
+	message, err := p.request(&server.Request{
+		Command:      "start_mission",
+		Type:         missionTypes[p.random.Intn(len(missionTypes))],
+		StartPlanets: []string{source},
+		EndPlanet:    target,
+		Fleet:        int32(10 + p.random.Intn(91)),
+	}, "send_missions")
+	if err != nil {
+		return err
+	}
+
+	var sent response.SendMissions
+	if err := json.Unmarshal(message, &sent); err != nil {
+		return err
+	}
+	for _, reason := range sent.FailedMissions {
+		p.stats.fail("send_missions", errors.New(reason))
+	}
+	return nil
+}
+
+// Sends the request and waits for the response with the expected command.
+// Everything else the server pushes in the meantime is only counted.
+func (p *player) request(request *server.Request, expected string) ([]byte, error) {
+	start := time.Now()
+	if err := websocket.JSON.Send(p.ws, request); err != nil {
+		return nil, err
+	}
+
+	for {
+		message, command, err := p.receive()
+		if err != nil {
+			return nil, err
+		}
+
+		switch command {
+		case expected:
+			p.stats.record(command, time.Since(start))
+			return message, nil
+		case "error":
+			var failure response.Error
+			json.Unmarshal(message, &failure)
+			return nil, errors.New(failure.Message)
+		default:
+			p.stats.push(command)
+		}
+	}
+}
+
+// Receives the next message and its command.
+func (p *player) receive() ([]byte, string, error) {
+	var message json.RawMessage
+	p.ws.SetReadDeadline(time.Now().Add(*timeout))
+	if err := websocket.JSON.Receive(p.ws, &message); err != nil {
+		return nil, "", err
+	}
+
+	var header struct{ Command string }
+	if err := json.Unmarshal(message, &header); err != nil {
+		return nil, "", err
+	}
+	return message, header.Command, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Collects the latencies of the responses, the messages pushed by the
+// server and the errors of all simulated players.
+type stats struct {
+	mutex     sync.Mutex
+	latencies map[string][]time.Duration
+	pushed    map[string]int
+	errors    map[string]int
+}
+
+func newStats() *stats {
+	return &stats{
+		latencies: make(map[string][]time.Duration),
+		pushed:    make(map[string]int),
+		errors:    make(map[string]int),
+	}
+}
+
+// Records how long it took the server to answer with the given command.
+func (s *stats) record(command string, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latencies[command] = append(s.latencies[command], latency)
+}
+
+// Counts a message the server sent on its own, like state_change.
+func (s *stats) push(command string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pushed[command]++
+}
+
+// Counts an error. Errors are grouped by what failed and the message.
+func (s *stats) fail(what string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errors[fmt.Sprintf("%s: %s", what, err)]++
+}
+
+// Returns the latency below which are p percent of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// Writes the latency percentiles of every response command, followed by
+// the pushed messages and the errors.
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fmt.Fprintf(w, "Finished in %s\n\n", elapsed)
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "command\tcount\tp50\tp90\tp99\tmax\t")
+	for _, command := range sortedKeys(s.latencies) {
+		latencies := s.latencies[command]
+		sort.Sort(durations(latencies))
+		fmt.Fprintf(
+			table,
+			"%s\t%d\t%s\t%s\t%s\t%s\t\n",
+			command,
+			len(latencies),
+			percentile(latencies, 50),
+			percentile(latencies, 90),
+			percentile(latencies, 99),
+			latencies[len(latencies)-1],
+		)
+	}
+	table.Flush()
+
+	if len(s.pushed) > 0 {
+		fmt.Fprintln(w, "\nPushed by the server:")
+		for _, command := range sortedKeys(s.pushed) {
+			fmt.Fprintf(w, "  %s: %d\n", command, s.pushed[command])
+		}
+	}
+
+	if len(s.errors) > 0 {
+		fmt.Fprintln(w, "\nErrors:")
+		for _, message := range sortedKeys(s.errors) {
+			fmt.Fprintf(w, "  %dx %s\n", s.errors[message], message)
+		}
+	}
+}
+
+// Returns the keys of a map[string]int or map[string][]time.Duration, sorted.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]int:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string][]time.Duration:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	for p, expected := range map[float64]time.Duration{
+		50: 50 * time.Millisecond,
+		90: 90 * time.Millisecond,
+		99: 99 * time.Millisecond,
+		0:  time.Millisecond,
+	} {
+		if result := percentile(latencies, p); result != expected {
+			t.Errorf("p%v is %s instead of %s", p, result, expected)
+		}
+	}
+
+	if result := percentile(nil, 50); result != 0 {
+		t.Errorf("p50 of nothing is %s", result)
+	}
+}
+
+func TestReport(t *testing.T) {
+	s := newStats()
+	s.record("scope_of_view_result", 3*time.Millisecond)
+	s.record("scope_of_view_result", time.Millisecond)
+	s.push("state_change")
+	s.fail("send_missions", errors.New("Not enough pilots on source planet!"))
+	s.fail("send_missions", errors.New("Not enough pilots on source planet!"))
+
+	var output bytes.Buffer
+	s.report(&output, time.Second)
+
+	for _, expected := range []string{
+		"scope_of_view_result      2",
+		"state_change: 1",
+		"2x send_missions: Not enough pilots on source planet!",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("%q is missing in the report:\n%s", expected, output.String())
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"warcluster/entities"
+	"warcluster/entities/db"
+	"warcluster/leaderboard"
+	"warcluster/replay"
+	"warcluster/server"
+	"warcluster/snapshot"
+)
+
+// All subcommands warcluster understands. Each of them receives the
+// command line arguments left after its name.
+var commands = map[string]func(args []string) error{
+	"serve":       serveCommand,
+	"snapshot":    snapshotCommand,
+	"migrate":     migrateCommand,
+	"inspect":     inspectCommand,
+	"leaderboard": leaderboardCommand,
+	"player":      playerCommand,
+	"replay":      replayCommand,
+	"universe":    universeCommand,
+}
+
+const commandsUsage = `  serve                       start the game server (default)
+  snapshot export [file]      write the whole universe as JSON
+  snapshot import <file>      load a universe written by snapshot export into an empty database
+  migrate                     rewrite all records to their latest schema version
+  inspect <key>               print a database record as JSON
+  leaderboard rebuild         recount the leaderboard and print it
+  player reset <name>         wipe a player, so he registers again on next login
+  replay <file> [speed]       stream a recorded game to spectators at /replay
+  universe stats              print entity counts and some totals
+
+All commands but serve work on the default universe, or on the one
+given with -universe. migrate goes through all universes unless one
+of them is given.
+`
+
+// Handles `warcluster snapshot export [file]` and `warcluster snapshot import <file>`.
+// Export writes to stdout when no file is given.
+func snapshotCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("Usage: warcluster snapshot export|import [file]")
+	}
+
+	switch args[0] {
+	case "export":
+		if len(args) < 2 {
+			return snapshot.Export(os.Stdout)
+		}
+		file, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return snapshot.Export(file)
+	case "import":
+		if len(args) < 2 {
+			return errors.New("Usage: warcluster snapshot import <file>")
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := snapshot.Import(file); err != nil {
+			return err
+		}
+
+		// The server recounts it on start as well, this only makes sure
+		// the restored universe adds up
+		board := leaderboard.New()
+		server.InitLeaderboard(board)
+		log.Printf("Restored a universe with %d players on the leaderboard.", board.Len())
+		return nil
+	}
+	return fmt.Errorf("Unknown snapshot command %q", args[0])
+}
+
+// Handles `warcluster migrate`. Rewrites all records to their latest schema
+// version and fails if any of them couldn't be migrated. The records of
+// all universes in the config are migrated, unless -universe is given.
+func migrateCommand(args []string) error {
+	migrated, errs := entities.Migrate()
+	if *universeID == "" {
+		for id, params := range cfg.Universe {
+			settings, err := cfg.UniverseEntities(id)
+			if err != nil {
+				return err
+			}
+			pool := db.NewPool(cfg.Database.Host, cfg.Database.Port, params.Database)
+			world, err := entities.NewWorld(pool, settings)
+			if err != nil {
+				return err
+			}
+			count, universeErrs := world.Migrate()
+			migrated += count
+			for _, err := range universeErrs {
+				errs = append(errs, fmt.Errorf("Universe %s: %s", id, err))
+			}
+		}
+	}
+
+	for _, err := range errs {
+		log.Println(err)
+	}
+	log.Printf("%d records migrated.", migrated)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d records failed to migrate", len(errs))
+	}
+	return nil
+}
+
+// Handles `warcluster inspect <key>`. Prints the entity stored at the given
+// key. If the key is an area, prints the keys of its members instead.
+func inspectCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: warcluster inspect <key>")
+	}
+	key := args[0]
+
+	conn := db.Pool.Get()
+	defer conn.Close()
+
+	if strings.HasPrefix(key, "area:") {
+		members, err := db.Smembers(conn, key)
+		if err != nil {
+			return err
+		}
+		sort.Strings(members)
+		return printJSON(members)
+	}
+
+	record, err := db.Get(conn, key)
+	if err != nil {
+		return fmt.Errorf("Fetching %s: %s", key, err)
+	}
+
+	entity, err := entities.Load(key, record)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Schema version: %d\n", entities.RecordVersion(record))
+	return printJSON(entity)
+}
+
+// Handles `warcluster leaderboard rebuild`. The leaderboard lives only in
+// memory, so this recounts it from the database the same way the server
+// does on start and prints it.
+func leaderboardCommand(args []string) error {
+	if len(args) != 1 || args[0] != "rebuild" {
+		return errors.New("Usage: warcluster leaderboard rebuild")
+	}
+
+	board := leaderboard.New()
+	server.InitLeaderboard(board)
+
+	var players []*leaderboard.Player
+	for page := int64(1); ; page++ {
+		boardPage, err := board.Page(page)
+		if err != nil || len(boardPage) == 0 {
+			break
+		}
+		players = append(players, boardPage...)
+	}
+
+	return printJSON(struct {
+		Players []*leaderboard.Player
+		Races   []*leaderboard.Race
+	}{players, board.Races()})
+}
+
+// Handles `warcluster player reset <name>`.
+func playerCommand(args []string) error {
+	if len(args) != 2 || args[0] != "reset" {
+		return errors.New("Usage: warcluster player reset <name>")
+	}
+	return resetPlayer(args[1])
+}
+
+// Wipes everything a player has: his solar system, missions, spy reports and
+// the player record itself. Planets he has conquered outside of his solar
+// system become neutral. On his next login he is registered from scratch.
+func resetPlayer(username string) error {
+	entity, err := entities.Get(fmt.Sprintf("player.%s", username))
+	if err != nil {
+		return fmt.Errorf("Can't find player %s: %s", username, err)
+	}
+	player := entity.(*entities.Player)
+	sunName := strings.TrimPrefix(player.Sun(), "planet.")
+	sunKey := fmt.Sprintf("sun.%s", sunName)
+
+	// The planets of the solar system are generated out of the sun, so
+	// they are found the same way whatever the planet count is
+	system := make(map[string]bool)
+	if entity, err := entities.Get(sunKey); err == nil {
+		planets, _ := entities.GeneratePlanets(username, entity.(*entities.Sun))
+		for _, planet := range planets {
+			system[planet.Name] = true
+		}
+	}
+
+	for _, entity := range entities.Find("planet.*") {
+		planet := entity.(*entities.Planet)
+		if system[planet.Name] {
+			entities.RemoveFromArea(planet.Key(), planet.AreaSet())
+			entities.Delete(planet.Key())
+		} else if planet.Owner == username {
+			planet.UpdateShipCount()
+			planet.Owner = ""
+			planet.Color = entities.NeutralPlanetColor
+			entities.Save(planet)
+		}
+	}
+
+	for _, entity := range entities.Find("ss.*") {
+		slot := entity.(*entities.SolarSlot)
+		if slot.Data == sunKey {
+			slot.Data = ""
+			entities.Save(slot)
+		}
+	}
+	if entity, err := entities.Get(sunKey); err == nil {
+		entities.RemoveFromArea(sunKey, entity.AreaSet())
+		entities.Delete(sunKey)
+	}
+
+	for _, entity := range entities.Find("mission.*") {
+		mission := entity.(*entities.Mission)
+		if mission.Player == username {
+			entities.Delete(mission.Key())
+		}
+	}
+
+	reports, _ := entities.GetList(fmt.Sprintf("spy_report.%s_*", username))
+	for _, key := range reports {
+		entities.Delete(key)
+	}
+	for _, entry := range entities.PlayerHistory(username) {
+		entities.Delete(entry.Key())
+	}
+
+	log.Printf("Player %s has been reset.", username)
+	return entities.Delete(player.Key())
+}
+
+// Handles `warcluster replay <file> [speed]`. Serves the recorded game on
+// the listen address, so every spectator connecting to /replay watches it
+// from the beginning.
+func replayCommand(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("Usage: warcluster replay <file> [speed]")
+	}
+
+	speed := 1.0
+	if len(args) == 2 {
+		var err error
+		if speed, err = strconv.ParseFloat(args[1], 64); err != nil || speed <= 0 {
+			return fmt.Errorf("Invalid speed %q", args[1])
+		}
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	events, err := replay.Read(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	host, port, err := listenAddress()
+	if err != nil {
+		return err
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(int(port)))
+	http.Handle("/replay", server.ReplayHandler(events, speed))
+	log.Printf("Replaying %d events at ws://%s/replay", len(events), address)
+	return http.ListenAndServe(address, nil)
+}
+
+// Handles `warcluster universe stats`.
+func universeCommand(args []string) error {
+	if len(args) != 1 || args[0] != "stats" {
+		return errors.New("Usage: warcluster universe stats")
+	}
+
+	var (
+		ownedPlanets  int
+		totalShips    int64
+		playersByRace = make(map[uint8]int)
+		missionsTypes = make(map[string]int)
+	)
+
+	players := entities.Find("player.*")
+	for _, entity := range players {
+		playersByRace[entity.(*entities.Player).RaceID]++
+	}
+
+	planets := entities.Find("planet.*")
+	for _, entity := range planets {
+		planet := entity.(*entities.Planet)
+		if planet.HasOwner() {
+			ownedPlanets++
+			totalShips += int64(planet.GetShipCount())
+		}
+	}
+
+	missions := entities.Find("mission.*")
+	for _, entity := range missions {
+		mission := entity.(*entities.Mission)
+		missionsTypes[mission.Type]++
+		totalShips += int64(mission.ShipCount)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(w, "Players:\t%d\n", len(players))
+	for _, race := range entities.Races {
+		fmt.Fprintf(w, "  %s:\t%d\n", race.Name, playersByRace[race.ID])
+	}
+	fmt.Fprintf(w, "Suns:\t%d\n", len(entities.Find("sun.*")))
+	fmt.Fprintf(w, "Planets:\t%d\n", len(planets))
+	fmt.Fprintf(w, "  owned:\t%d\n", ownedPlanets)
+	fmt.Fprintf(w, "  neutral:\t%d\n", len(planets)-ownedPlanets)
+	fmt.Fprintf(w, "Missions in flight:\t%d\n", len(missions))
+	for _, missionType := range []string{"Attack", "Supply", "Spy"} {
+		fmt.Fprintf(w, "  %s:\t%d\n", missionType, missionsTypes[missionType])
+	}
+	fmt.Fprintf(w, "Ships owned by players:\t%d\n", totalShips)
+	return w.Flush()
+}
+
+func printJSON(v interface{}) error {
+	result, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import "time"
+
+// Names of all buildings a planet could be upgraded with.
+const (
+	Shipyard = "shipyard"
+	Defences = "defences"
+	Radar    = "radar"
+	Storage  = "storage"
+)
+
+// All buildings known to the game.
+var BuildingNames = []string{Shipyard, Defences, Radar, Storage}
+
+// Building describes one of the planet upgrades.
+//
+// Building level N costs N times Cost ships and N times EnergyCost energy
+// (both taken from the planet itself) and takes N times BuildTime seconds. Every level adds Bonus to the
+// modifier of whatever the building is good for:
+//
+//	shipyard - ships produced per minute
+//	defences - strength of the ships defending the planet
+//	radar    - scope of view (for home planets) and spy report validity
+//	storage  - population cap of the planet
+type Building struct {
+	Cost       int32
+	EnergyCost float64
+	BuildTime  time.Duration // in seconds
+	MaxLevel   uint8
+	Bonus      float64
+}
+
+// BuildingTable is keyed by the building name.
+//
+//	[building "shipyard"]
+//	    cost = 200
+//	    energyCost = 100
+//	    buildTime = 60
+//	    maxLevel = 5
+//	    bonus = 0.25
+type BuildingTable map[string]*Building
+
+// Returns the buildings used when the config has none.
+func DefaultBuildings() BuildingTable {
+	return BuildingTable{
+		Shipyard: {Cost: 200, EnergyCost: 100, BuildTime: 60, MaxLevel: 5, Bonus: 0.25},
+		Defences: {Cost: 200, EnergyCost: 100, BuildTime: 60, MaxLevel: 5, Bonus: 0.25},
+		Radar:    {Cost: 150, EnergyCost: 150, BuildTime: 45, MaxLevel: 5, Bonus: 0.25},
+		Storage:  {Cost: 150, EnergyCost: 50, BuildTime: 45, MaxLevel: 5, Bonus: 0.5},
+	}
+}
+
+// Returns how many ships the given level costs.
+func (b *Building) LevelCost(level uint8) int32 {
+	return b.Cost * int32(level)
+}
+
+// Returns how much energy the given level costs.
+func (b *Building) LevelEnergyCost(level uint8) float64 {
+	return b.EnergyCost * float64(level)
+}
+
+// Returns how long it takes to build the given level.
+func (b *Building) LevelBuildTime(level uint8) time.Duration {
+	return b.BuildTime * time.Duration(level) * time.Second
+}
+
+// Returns the modifier of a building with the given level.
+// It is 1 when there is no such building at all.
+func (b *Building) Modifier(level uint8) float64 {
+	return 1 + b.Bonus*float64(level)
+}
@@ -2,21 +2,29 @@
 package config
 
 import (
-	"log"
+	"fmt"
 	"os"
 	"path"
 	"runtime"
+	"strings"
 	"time"
 
 	"code.google.com/p/gcfg"
 )
 
+// Prefix of all environment variables overriding config values.
+// WARCLUSTER_ENTITIES_MISSIONSPEED=8 sets missionSpeed in [entities].
+const EnvPrefix = "WARCLUSTER_"
+
+const redacted = "[redacted]"
+
 type Config struct {
 	Server struct {
-		Host    string
-		Port    uint16
-		Console bool
-		Ticker  time.Duration
+		Host      string
+		Port      uint16
+		Console   bool
+		Ticker    time.Duration
+		ReplayLog string
 	}
 	Database struct {
 		Host string
@@ -27,64 +35,273 @@ type Config struct {
 		ConsumerSecret string
 		SecureLogin    bool
 	}
+	Admin struct {
+		Token string
+	}
 	Race map[string]*struct {
 		Id    uint8
 		Red   float32
 		Green float32
 		Blue  float32
 	}
-	Entities Entities
+	Production ProductionTable
+	Building   BuildingTable
+	Speed      SpeedTable
+	Entities   Entities
+
+	// Universes hosted next to the default one, keyed by their id.
+	// Each one is stored in a database of its own and is played by
+	// [entities] with the values in Set (name=value) on top.
+	// A universe with a TournamentStart (RFC 3339) hosts a tournament
+	// lasting TournamentDuration seconds and won by TournamentWin.
+	Universe map[string]*struct {
+		Database           uint8
+		ReplayLog          string
+		Set                []string
+		TournamentStart    time.Time
+		TournamentDuration time.Duration
+		TournamentWin      string
+	}
 }
 
 type Entities struct {
 	AreaSize                   int64
 	AreaTemplate               string
+	BotInterval                time.Duration
+	HistorySize                int
+	HomePlanetSiege            time.Duration
 	InitialHomePlanetShipCount int32
 	InitialPlanetShipCount     int32
+	MaxCoordinate              float64
+	MaxPathLength              float64
+	MaxWaypoints               int
+	MinSpyReportInterval       time.Duration
+	MissionBoostEnergy         float64
+	MissionBoostSpeed          float64
 	MissionSpeed               int64
+	NeutralMaxShipCount        int32
+	NewbieProtection           time.Duration
+	NewbieProtectionPlanets    int
+	NeutralShipsPerMinute      float64
 	PlanetCount                int
 	PlanetHashArgs             int
 	PlanetRadius               uint16
-	ShipsPerMinute1            float64
-	ShipsPerMinute2            float64
-	ShipsPerMinute3            float64
-	ShipsPerMinute4            float64
-	ShipsPerMinute5            float64
-	ShipsPerMinute6            float64
-	ShipsPerMinute7            float64
-	ShipsPerMinute8            float64
-	ShipsPerMinute9            float64
-	ShipsPerMinute10           float64
-	ShipsPerMinuteHome         float64
-	ShipsDeathModifier         float64
-	PlanetMaxShipsMod          int64
 	PlanetsRingOffset          uint16
+	PirateFactions             int
+	PirateRaidFleet            int32
+	PirateRaidInterval         time.Duration
+	PirateRaidMinShips         int32
+	PirateRaidRange            float64
 	SolarSystemRadius          float64
+	SpyDetectionChance         float64
+	SpyDetectionKills          float64
+	SpyDetectionMaxChance      float64
+	SpyDetectionPerShip        float64
+	SpyEnergy                  float64
 	SpyReportValidity          time.Duration
 	SunCanvasOffsetX           uint64
 	SunCanvasOffsetY           uint64
 	SunTextures                uint16
+	VacationCooldown           time.Duration
+	VacationMinDuration        time.Duration
+
+	// The very same tables as Config.Production, Config.Building and
+	// Config.Speed. They are kept here as well, so everything gameplay related is swapped
+	// together on reload.
+	Production ProductionTable `json:"-"`
+	Buildings  BuildingTable   `json:"-"`
+	Speeds     SpeedTable      `json:"-"`
 }
 
+// ConfigDir is the directory of this very source file. The default
+// config files are looked up there unless a path is given explicitly.
 var ConfigDir string
 
 func init() {
-	_, filename, _, _ := runtime.Caller(1)
+	_, filename, _, _ := runtime.Caller(0)
 	ConfigDir = path.Dir(filename)
 }
 
-func (c *Config) Load() {
+// Read builds the effective config. It loads the file at the given path
+// (or the default one if the path is empty), applies the environment
+// variables and the overrides on top of it and validates the result.
+func Read(filename string, environ []string, overrides Overrides) (Config, error) {
+	var (
+		c   Config
+		err error
+	)
+
+	if filename == "" {
+		err = c.Load()
+	} else {
+		err = c.LoadFile(filename)
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err = c.LoadEnv(environ); err != nil {
+		return c, err
+	}
+
+	if err = overrides.Apply(&c); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// Loads config.gcfg from ConfigDir, falling back to the default one.
+func (c *Config) Load() error {
 	if err := gcfg.ReadFileInto(c, path.Join(ConfigDir, "config.gcfg")); err != nil {
 		if os.IsNotExist(err) {
-			c.LoadDefault()
-		} else {
-			log.Fatal("Error loading cfg:", err)
+			return c.LoadDefault()
 		}
+		return fmt.Errorf("Error loading cfg: %s", err)
 	}
+	c.setDefaults()
+	return nil
 }
 
-func (c *Config) LoadDefault() {
+// Loads the config file at the given path.
+func (c *Config) LoadFile(filename string) error {
+	if err := gcfg.ReadFileInto(c, filename); err != nil {
+		return fmt.Errorf("Error loading cfg %s: %s", filename, err)
+	}
+	c.setDefaults()
+	return nil
+}
+
+func (c *Config) LoadDefault() error {
 	if err := gcfg.ReadFileInto(c, path.Join(ConfigDir, "config.gcfg.default")); err != nil {
-		log.Fatal("Error loading default cfg:", err)
+		return fmt.Errorf("Error loading default cfg: %s", err)
+	}
+	c.setDefaults()
+	return nil
+}
+
+// Fills everything which is optional in the config file.
+func (c *Config) setDefaults() {
+	if len(c.Production) == 0 {
+		c.Production = DefaultProduction()
+	}
+	if len(c.Building) == 0 {
+		c.Building = DefaultBuildings()
+	}
+	if len(c.Speed) == 0 {
+		c.Speed = DefaultSpeeds()
+	}
+	c.Entities.Production = c.Production
+	c.Entities.Buildings = c.Building
+	c.Entities.Speeds = c.Speed
+}
+
+// Sets all values given as environment variables in the form of
+// WARCLUSTER_<SECTION>_<NAME>=<value>. Names are case insensitive.
+// Subsections (like races) could be changed only with overrides.
+func (c *Config) LoadEnv(environ []string) error {
+	var overrides Overrides
+
+	for _, variable := range environ {
+		if !strings.HasPrefix(variable, EnvPrefix) {
+			continue
+		}
+		pair := strings.SplitN(strings.TrimPrefix(variable, EnvPrefix), "=", 2)
+		parts := strings.SplitN(pair[0], "_", 2)
+		if len(pair) != 2 || len(parts) != 2 {
+			return fmt.Errorf("Invalid config environment variable %s", variable)
+		}
+		overrides = append(overrides, fmt.Sprintf("%s.%s=%s", parts[0], parts[1], pair[1]))
 	}
+	return overrides.Apply(c)
+}
+
+// Returns the gameplay settings of the given universe.
+func (c Config) UniverseEntities(id string) (Entities, error) {
+	universe, ok := c.Universe[id]
+	if !ok {
+		return Entities{}, fmt.Errorf("Unknown universe %q", id)
+	}
+
+	// c is a copy, so only its own Entities are changed
+	var overrides Overrides
+	for _, value := range universe.Set {
+		overrides = append(overrides, fmt.Sprintf("entities.%s", value))
+	}
+	if err := overrides.Apply(&c); err != nil {
+		return Entities{}, fmt.Errorf("Universe %q: %s", id, err)
+	}
+	return c.Entities, nil
+}
+
+// Returns a copy of the config with all secrets replaced, so it could be
+// shown to humans.
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{
+		&c.Twitter.ConsumerKey,
+		&c.Twitter.ConsumerSecret,
+		&c.Admin.Token,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return c
+}
+
+// Overrides is a list of config values in the form of section.name=value
+// or section.subsection.name=value. It implements flag.Value, so it could
+// be given multiple times on the command line.
+type Overrides []string
+
+func (o *Overrides) String() string {
+	return strings.Join(*o, ", ")
+}
+
+func (o *Overrides) Set(value string) error {
+	if _, _, err := parseOverride(value); err != nil {
+		return err
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// Applies all overrides in order on the given config. They are parsed by
+// gcfg itself, so they accept exactly what the config file accepts.
+func (o Overrides) Apply(c *Config) error {
+	for _, override := range o {
+		section, value, err := parseOverride(override)
+		if err != nil {
+			return err
+		}
+		if err := gcfg.ReadStringInto(c, fmt.Sprintf("%s\n%s", section, value)); err != nil {
+			return fmt.Errorf("Error applying %q: %s", override, err)
+		}
+	}
+	return nil
+}
+
+// Splits section.name=value into a gcfg section header and a variable line.
+func parseOverride(override string) (string, string, error) {
+	pair := strings.SplitN(override, "=", 2)
+	if len(pair) != 2 {
+		return "", "", fmt.Errorf("Override %q is not in the form of section.name=value", override)
+	}
+
+	parts := strings.Split(pair[0], ".")
+	var section string
+	switch len(parts) {
+	case 2:
+		section = fmt.Sprintf("[%s]", parts[0])
+	case 3:
+		section = fmt.Sprintf("[%s %s]", parts[0], quote(parts[1]))
+	default:
+		return "", "", fmt.Errorf("Override %q is not in the form of section.name=value", override)
+	}
+	return section, fmt.Sprintf("%s = %s", parts[len(parts)-1], quote(pair[1])), nil
+}
+
+// Quotes a value the way gcfg expects it.
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return fmt.Sprintf(`"%s"`, replacer.Replace(value))
 }
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadDefault(t *testing.T) Config {
+	var c Config
+	if err := c.LoadDefault(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	c := loadDefault(t)
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	c := loadDefault(t)
+	overrides := Overrides{
+		"entities.missionSpeed=8",
+		"server.host=127.0.0.1",
+		"entities.areaTemplate=area \"%d\" %d",
+		"race.InitLab.red=0.5",
+	}
+
+	if err := overrides.Apply(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Entities.MissionSpeed != 8 {
+		t.Errorf("Mission speed is %d instead of 8", c.Entities.MissionSpeed)
+	}
+	if c.Server.Host != "127.0.0.1" {
+		t.Errorf("Host is %s instead of 127.0.0.1", c.Server.Host)
+	}
+	if c.Entities.AreaTemplate != `area "%d" %d` {
+		t.Errorf("Area template is %s", c.Entities.AreaTemplate)
+	}
+	if c.Race["InitLab"].Red != 0.5 {
+		t.Errorf("InitLab's red is %f instead of 0.5", c.Race["InitLab"].Red)
+	}
+	if c.Server.Port != 7000 {
+		t.Errorf("Port has changed to %d", c.Server.Port)
+	}
+}
+
+func TestInvalidOverrides(t *testing.T) {
+	var overrides Overrides
+	for _, override := range []string{"missionSpeed=8", "entities.missionSpeed", "a.b.c.d=1"} {
+		if err := overrides.Set(override); err == nil {
+			t.Errorf("Override %q has been accepted", override)
+		}
+	}
+
+	c := loadDefault(t)
+	for _, override := range []string{"entities.missionSpeed=fast", "entities.panda=1"} {
+		if err := (Overrides{override}).Apply(&c); err == nil {
+			t.Errorf("Override %q has been applied", override)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	c := loadDefault(t)
+	environ := []string{
+		"HOME=/home/gophie",
+		"WARCLUSTER_DATABASE_PORT=6380",
+		"WARCLUSTER_ENTITIES_SPYREPORTVALIDITY=60",
+	}
+
+	if err := c.LoadEnv(environ); err != nil {
+		t.Fatal(err)
+	}
+	if c.Database.Port != 6380 {
+		t.Errorf("Database port is %d instead of 6380", c.Database.Port)
+	}
+	if c.Entities.SpyReportValidity != 60 {
+		t.Errorf("Spy report validity is %d instead of 60", c.Entities.SpyReportValidity)
+	}
+
+	if err := c.LoadEnv([]string{"WARCLUSTER_PANDA=1"}); err == nil {
+		t.Error("Variable without a section has been applied")
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	c := loadDefault(t)
+	c.Entities.MissionSpeed = 0
+	c.Production["4"].ShipsPerMinute = 0
+	c.Server.Ticker = -1 * time.Millisecond
+
+	err := c.Validate()
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Validate returned %#v", err)
+	}
+	if len(validationErr) != 3 {
+		t.Errorf("Expected 3 errors, got %s", err)
+	}
+	for _, field := range []string{"missionSpeed", `production "4": shipsPerMinute`, "ticker"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("%s is not reported as invalid", field)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := loadDefault(t)
+	c.Admin.Token = "secret"
+	redactedConfig := c.Redacted()
+
+	if redactedConfig.Admin.Token != redacted || redactedConfig.Twitter.ConsumerSecret != redacted {
+		t.Error("Secrets are not redacted")
+	}
+	if c.Admin.Token != "secret" {
+		t.Error("Redacted changed the original config")
+	}
+}
+
+func TestUniverseEntities(t *testing.T) {
+	c := loadDefault(t)
+	overrides := Overrides{
+		"universe.tournament.database=9",
+		"universe.tournament.set=missionSpeed=12",
+		"universe.tournament.set=pirateFactions=2",
+	}
+	if err := overrides.Apply(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := c.UniverseEntities("tournament")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.MissionSpeed != 12 || settings.PirateFactions != 2 {
+		t.Errorf("Mission speed %d and %d pirate factions", settings.MissionSpeed, settings.PirateFactions)
+	}
+	if c.Entities.MissionSpeed == 12 {
+		t.Error("The settings of the default universe have changed")
+	}
+	if _, err := c.UniverseEntities("public"); err == nil {
+		t.Error("Unknown universe has settings")
+	}
+
+	c.Universe["tournament"].Set = append(c.Universe["tournament"].Set, "missionSpeed=0")
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), `universe "tournament": entities.missionSpeed`) {
+		t.Errorf("Invalid universe settings are accepted: %v", err)
+	}
+}
+
+func TestTournament(t *testing.T) {
+	c := loadDefault(t)
+	overrides := Overrides{
+		"universe.tournament.database=9",
+		"universe.tournament.tournamentStart=2015-06-01T18:00:00Z",
+		"universe.tournament.tournamentDuration=7200",
+		"universe.tournament.tournamentWin=race_planets",
+	}
+	if err := overrides.Apply(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tournament := c.Universe["tournament"]
+	if start := time.Date(2015, 6, 1, 18, 0, 0, 0, time.UTC); !tournament.TournamentStart.Equal(start) {
+		t.Errorf("The tournament starts at %s", tournament.TournamentStart)
+	}
+	if tournament.TournamentWin != WinMostRacePlanets {
+		t.Errorf("The tournament is won by %q", tournament.TournamentWin)
+	}
+
+	tournament.TournamentWin = "most_ships"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "tournamentWin") {
+		t.Errorf("Unknown win condition is accepted: %v", err)
+	}
+	tournament.TournamentWin = WinMostPlanets
+	tournament.TournamentDuration = 0
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "tournamentDuration") {
+		t.Errorf("A tournament without a duration is accepted: %v", err)
+	}
+}
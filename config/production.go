@@ -0,0 +1,116 @@
+package config
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Key of the production table row used for home planets.
+const HomeProduction = "home"
+
+// Production describes how a planet of some size grows its army.
+//
+// ShipsPerMinute is how many pilots join the army every minute.
+// The population cap of the planet is MaxShipsMod times that (in whole ships).
+// When the army is bigger than the cap, pilots die DeathModifier times
+// faster than they are born. EnergyPerMinute is how much energy the planet
+// gathers every minute.
+type Production struct {
+	ShipsPerMinute  float64
+	MaxShipsMod     int64
+	DeathModifier   float64
+	EnergyPerMinute float64
+}
+
+// ProductionTable is keyed by the planet size, written as a string,
+// because that's how it comes out of the config file:
+//
+//	[production "1"]
+//	    shipsPerMinute = 2
+//	    maxShipsMod = 1000
+//	    deathModifier = 3
+//	    energyPerMinute = 0.5
+//
+// The row keyed by HomeProduction is used for all home planets.
+type ProductionTable map[string]*Production
+
+// Returns the production table used when the config has none.
+func DefaultProduction() ProductionTable {
+	table := ProductionTable{
+		HomeProduction: {ShipsPerMinute: 6, MaxShipsMod: 1000, DeathModifier: 3, EnergyPerMinute: 10},
+	}
+	for size, secondsPerShip := range []float64{30, 30, 27, 27, 27, 25, 25, 25, 20, 20} {
+		table[strconv.Itoa(size+1)] = &Production{
+			ShipsPerMinute:  60 / secondsPerShip,
+			MaxShipsMod:     1000,
+			DeathModifier:   3,
+			EnergyPerMinute: float64(size+1) / 2,
+		}
+	}
+	return table
+}
+
+// Returns the seconds needed for a single ship to be produced.
+// It is never less than a second.
+func (p *Production) SecondsPerShip() int64 {
+	return int64(math.Max(1, math.Floor(60/p.ShipsPerMinute+0.5)))
+}
+
+// Returns the population cap of a planet with this production.
+func (p *Production) MaxShipCount() int32 {
+	return int32(p.MaxShipsMod * (60 / p.SecondsPerShip()))
+}
+
+// Returns all planet sizes in the table in ascending order.
+func (t ProductionTable) Sizes() []int8 {
+	sizes := make([]int8, 0, len(t))
+	for key := range t {
+		if size, err := strconv.ParseInt(key, 10, 8); err == nil {
+			sizes = append(sizes, int8(size))
+		}
+	}
+	sort.Sort(int8Slice(sizes))
+	return sizes
+}
+
+// Returns the production of the given planet. If there is no row for its
+// size the closest smaller size is used, or the smallest one if there is
+// no smaller size at all. That's why changing the table never breaks
+// planets generated before the change.
+func (t ProductionTable) For(size int8, isHome bool) *Production {
+	if isHome {
+		if row, ok := t[HomeProduction]; ok {
+			return row
+		}
+	}
+	if row, ok := t[strconv.Itoa(int(size))]; ok {
+		return row
+	}
+
+	sizes := t.Sizes()
+	if len(sizes) == 0 {
+		return t[HomeProduction]
+	}
+	closest := sizes[0]
+	for _, s := range sizes {
+		if s <= size {
+			closest = s
+		}
+	}
+	return t[strconv.Itoa(int(closest))]
+}
+
+type int8Slice []int8
+
+func (s int8Slice) Len() int {
+	return len(s)
+}
+
+func (s int8Slice) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s int8Slice) Less(i, j int) bool {
+	return s[i] < s[j]
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestDefaultProduction(t *testing.T) {
+	table := DefaultProduction()
+
+	for size, expected := range map[int8]int64{1: 30, 3: 27, 6: 25, 10: 20} {
+		if secondsPerShip := table.For(size, false).SecondsPerShip(); secondsPerShip != expected {
+			t.Errorf("Size %d produces a ship every %ds instead of %ds", size, secondsPerShip, expected)
+		}
+	}
+	if secondsPerShip := table.For(5, true).SecondsPerShip(); secondsPerShip != 10 {
+		t.Errorf("Home planets produce a ship every %ds instead of 10s", secondsPerShip)
+	}
+	if maxShipCount := table.For(10, false).MaxShipCount(); maxShipCount != 3000 {
+		t.Errorf("Size 10 is capped at %d instead of 3000", maxShipCount)
+	}
+}
+
+func TestProductionFallback(t *testing.T) {
+	table := ProductionTable{
+		HomeProduction: {ShipsPerMinute: 6},
+		"3":            {ShipsPerMinute: 3},
+		"7":            {ShipsPerMinute: 7},
+	}
+
+	sizes := table.Sizes()
+	if len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 7 {
+		t.Errorf("Sizes returned %v", sizes)
+	}
+
+	for size, expected := range map[int8]float64{1: 3, 3: 3, 5: 3, 7: 7, 10: 7} {
+		if row := table.For(size, false); row.ShipsPerMinute != expected {
+			t.Errorf("Size %d uses %v ships per minute instead of %v", size, row.ShipsPerMinute, expected)
+		}
+	}
+}
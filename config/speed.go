@@ -0,0 +1,47 @@
+package config
+
+import "math"
+
+// How the speed of a mission is calculated. It is sent to the clients, so
+// they could predict when a mission is going to arrive.
+const SpeedFormula = "speed = base * (referenceFleet / ships) ^ exponent, kept between min and max; " +
+	"boosted missions are missionBoostSpeed times faster; travelTime (ms) = distance / speed * 100"
+
+// Speed is the curve of the speed of a mission type by its fleet size.
+// Exponent 0 means all fleets travel with the base speed, the bigger it
+// is the slower big fleets are (and the faster small ones).
+type Speed struct {
+	Base           float64
+	ReferenceFleet float64
+	Exponent       float64
+	Min            float64
+	Max            float64
+}
+
+// SpeedTable is keyed by the mission type.
+//
+//	[speed "Attack"]
+//	    base = 6
+//	    referenceFleet = 100
+//	    exponent = 0.25
+//	    min = 2
+//	    max = 9
+//
+// Mission types missing from the table travel with entities.missionSpeed.
+type SpeedTable map[string]*Speed
+
+// Returns the speed curves used when the config has none.
+func DefaultSpeeds() SpeedTable {
+	return SpeedTable{
+		"Attack": {Base: 6, ReferenceFleet: 100, Exponent: 0.25, Min: 2, Max: 9},
+		"Supply": {Base: 6, ReferenceFleet: 100, Exponent: 0.15, Min: 3, Max: 8},
+		"Spy":    {Base: 12, ReferenceFleet: 1, Exponent: 0, Min: 12, Max: 12},
+	}
+}
+
+// Returns the speed of a fleet with the given number of ships.
+func (s *Speed) For(ships int32) float64 {
+	fleet := math.Max(1, float64(ships))
+	speed := s.Base * math.Pow(s.ReferenceFleet/fleet, s.Exponent)
+	return math.Min(s.Max, math.Max(s.Min, speed))
+}
@@ -0,0 +1,13 @@
+package config
+
+import "testing"
+
+func TestSpeedCurve(t *testing.T) {
+	speed := Speed{Base: 6, ReferenceFleet: 100, Exponent: 0.5, Min: 2, Max: 9}
+
+	for ships, expected := range map[int32]float64{100: 6, 400: 3, 10000: 2, 25: 9, 0: 9} {
+		if actual := speed.For(ships); actual != expected {
+			t.Errorf("%d ships travel with %f instead of %f", ships, actual, expected)
+		}
+	}
+}
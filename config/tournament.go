@@ -0,0 +1,23 @@
+package config
+
+// Win conditions of a tournament.
+const (
+	// The player with the most planets wins
+	WinMostPlanets = "planets"
+	// The race with the most planets of its players wins
+	WinMostRacePlanets = "race_planets"
+	// The last player who still owns their home planet wins
+	WinLastHomeStanding = "last_home"
+)
+
+// All win conditions known to the game.
+var WinConditions = []string{WinMostPlanets, WinMostRacePlanets, WinLastHomeStanding}
+
+func isWinCondition(name string) bool {
+	for _, condition := range WinConditions {
+		if name == condition {
+			return true
+		}
+	}
+	return false
+}
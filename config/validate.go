@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidationError lists every invalid field of a config.
+type ValidationError []string
+
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("Invalid config:\n  %s", strings.Join(v, "\n  "))
+}
+
+// Validate checks all values which could break the game in one way or
+// another and reports all of them at once.
+func (c *Config) Validate() error {
+	var errs ValidationError
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0, "server.port must be set")
+	check(c.Server.Ticker > 0, "server.ticker must be positive")
+	check(c.Database.Port > 0, "database.port must be set")
+	if c.Twitter.SecureLogin {
+		check(c.Twitter.ConsumerKey != "", "twitter.consumerKey is required for secure login")
+		check(c.Twitter.ConsumerSecret != "", "twitter.consumerSecret is required for secure login")
+	}
+
+	check(len(c.Race) > 0, "at least one race is required")
+	ids := make(map[uint8]string)
+	for name, race := range c.Race {
+		if other, ok := ids[race.Id]; ok {
+			check(false, "race %q has the same id as race %q", name, other)
+		}
+		ids[race.Id] = name
+		check(int(race.Id) < len(c.Race), "race %q has id %d, but ids must be between 0 and %d", name, race.Id, len(c.Race)-1)
+		for component, value := range map[string]float32{"red": race.Red, "green": race.Green, "blue": race.Blue} {
+			check(value >= 0 && value <= 1, "race %q: %s must be between 0 and 1", name, component)
+		}
+	}
+
+	c.Entities.validate(check)
+
+	databases := make(map[uint8]string)
+	for id, universe := range c.Universe {
+		check(id != "" && !strings.Contains(id, "/"), "universe %q: the id can't be empty or contain /", id)
+		check(universe.Database <= 15, "universe %q: database must be between 0 and 15", id)
+		if other, ok := databases[universe.Database]; ok {
+			check(false, "universe %q has the same database as universe %q", id, other)
+		}
+		databases[universe.Database] = id
+		if !universe.TournamentStart.IsZero() {
+			check(universe.TournamentDuration > 0, "universe %q: tournamentDuration must be positive", id)
+			check(isWinCondition(universe.TournamentWin), "universe %q: tournamentWin must be one of %s", id, strings.Join(WinConditions, ", "))
+		}
+
+		settings, err := c.UniverseEntities(id)
+		if err != nil {
+			check(false, "%s", err)
+			continue
+		}
+		settings.validate(func(ok bool, format string, args ...interface{}) {
+			check(ok, "universe %q: %s", id, fmt.Sprintf(format, args...))
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (e *Entities) validate(check func(bool, string, ...interface{})) {
+	check(e.AreaSize > 0, "entities.areaSize must be positive")
+	check(strings.Count(e.AreaTemplate, "%d") == 2, "entities.areaTemplate must contain exactly two %%d")
+	check(e.BotInterval > 0, "entities.botInterval must be positive")
+	check(e.HistorySize > 0, "entities.historySize must be positive")
+	check(e.HomePlanetSiege >= 0, "entities.homePlanetSiege can't be negative")
+	check(e.InitialHomePlanetShipCount >= 0, "entities.initialHomePlanetShipCount can't be negative")
+	check(e.InitialPlanetShipCount >= 0, "entities.initialPlanetShipCount can't be negative")
+	check(e.MaxCoordinate > 0, "entities.maxCoordinate must be positive")
+	check(e.MaxPathLength > 0, "entities.maxPathLength must be positive")
+	check(e.MaxWaypoints >= 0, "entities.maxWaypoints can't be negative")
+	check(e.MinSpyReportInterval > 0, "entities.minSpyReportInterval must be positive")
+	check(e.MissionBoostEnergy >= 0, "entities.missionBoostEnergy can't be negative")
+	check(e.MissionBoostSpeed >= 1, "entities.missionBoostSpeed must be at least 1")
+	check(e.MissionSpeed > 0, "entities.missionSpeed must be positive")
+	check(e.NeutralMaxShipCount >= 0, "entities.neutralMaxShipCount can't be negative")
+	check(e.NeutralShipsPerMinute >= 0, "entities.neutralShipsPerMinute can't be negative")
+	check(e.NewbieProtection >= 0, "entities.newbieProtection can't be negative")
+	check(e.NewbieProtectionPlanets >= 0, "entities.newbieProtectionPlanets can't be negative")
+	check(e.PlanetCount > 0, "entities.planetCount must be positive")
+	check(e.PlanetHashArgs >= 4, "entities.planetHashArgs must be at least 4")
+	// Planets are generated out of a 64 digits hash of the username
+	check(e.PlanetCount*e.PlanetHashArgs < 63, "entities.planetCount * entities.planetHashArgs must be less than 63")
+	check(e.PlanetRadius > 0, "entities.planetRadius must be positive")
+	check(e.PirateFactions >= 0, "entities.pirateFactions can't be negative")
+	check(e.PirateRaidFleet > 0 && e.PirateRaidFleet <= 100, "entities.pirateRaidFleet must be between 1 and 100")
+	check(e.PirateRaidInterval > 0, "entities.pirateRaidInterval must be positive")
+	check(e.PirateRaidMinShips > 0, "entities.pirateRaidMinShips must be positive")
+	check(e.PirateRaidRange > 0, "entities.pirateRaidRange must be positive")
+
+	check(e.Production[HomeProduction] != nil, "production %q is required", HomeProduction)
+	check(len(e.Production.Sizes()) > 0, "at least one production row for a planet size is required")
+	for key, row := range e.Production {
+		if key != HomeProduction {
+			size, err := strconv.ParseInt(key, 10, 8)
+			check(err == nil && size > 0, "production %q: the planet size must be between 1 and 127", key)
+		}
+		check(row.ShipsPerMinute > 0, "production %q: shipsPerMinute must be positive", key)
+		check(row.MaxShipsMod > 0, "production %q: maxShipsMod must be positive", key)
+		check(row.DeathModifier >= 0, "production %q: deathModifier can't be negative", key)
+		check(row.EnergyPerMinute >= 0, "production %q: energyPerMinute can't be negative", key)
+	}
+
+	for _, name := range BuildingNames {
+		check(e.Buildings[name] != nil, "building %q is required", name)
+	}
+	for name, building := range e.Buildings {
+		check(isBuilding(name), "building %q is unknown", name)
+		check(building.Cost > 0, "building %q: cost must be positive", name)
+		check(building.EnergyCost >= 0, "building %q: energyCost can't be negative", name)
+		check(building.BuildTime >= 0, "building %q: buildTime can't be negative", name)
+		check(building.MaxLevel > 0, "building %q: maxLevel must be positive", name)
+		check(building.Bonus >= 0, "building %q: bonus can't be negative", name)
+	}
+
+	for missionType, speed := range e.Speeds {
+		check(speed.Base > 0, "speed %q: base must be positive", missionType)
+		check(speed.ReferenceFleet > 0, "speed %q: referenceFleet must be positive", missionType)
+		check(speed.Exponent >= 0, "speed %q: exponent can't be negative", missionType)
+		check(speed.Min > 0, "speed %q: min must be positive", missionType)
+		check(speed.Max >= speed.Min, "speed %q: max can't be less than min", missionType)
+	}
+	check(e.SolarSystemRadius > 0, "entities.solarSystemRadius must be positive")
+	check(e.SpyDetectionChance >= 0, "entities.spyDetectionChance can't be negative")
+	check(e.SpyDetectionKills > 0 && e.SpyDetectionKills <= 1, "entities.spyDetectionKills must be between 0 and 1")
+	check(e.SpyDetectionMaxChance >= 0 && e.SpyDetectionMaxChance <= 1, "entities.spyDetectionMaxChance must be between 0 and 1")
+	check(e.SpyDetectionPerShip >= 0, "entities.spyDetectionPerShip can't be negative")
+	check(e.SpyEnergy >= 0, "entities.spyEnergy can't be negative")
+	check(e.SpyReportValidity > 0, "entities.spyReportValidity must be positive")
+	check(e.VacationCooldown >= 0, "entities.vacationCooldown can't be negative")
+	check(e.VacationMinDuration >= 0, "entities.vacationMinDuration can't be negative")
+}
+
+func isBuilding(name string) bool {
+	for _, building := range BuildingNames {
+		if name == building {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"errors"
+
+	"warcluster/clock"
+	"warcluster/config"
+)
+
+// Construction is an upgrade of a planet, which is still being built.
+type Construction struct {
+	Building   string
+	Level      uint8
+	FinishesAt int64
+}
+
+// Returns the level of the given building on the planet.
+func (p *Planet) BuildingLevel(building string) uint8 {
+	return p.Buildings[building]
+}
+
+// Returns the modifier the given building gives to this planet.
+// Buildings removed from the config have no effect at all.
+func (p *Planet) BuildingModifier(building string) float64 {
+	settings, ok := p.world.Settings().Buildings[building]
+	if !ok {
+		return 1
+	}
+	return settings.Modifier(p.BuildingLevel(building))
+}
+
+// Starts building the next level of the given building. The ships and the
+// energy are taken from the planet right away and the level is applied once the
+// construction is finished (see UpdateShipCount). Only one building could
+// be constructed on a planet at a time.
+func (p *Planet) StartUpgrade(building string) (*Construction, error) {
+	settings, ok := p.world.Settings().Buildings[building]
+	if !ok {
+		return nil, errors.New("Unknown building")
+	}
+
+	p.UpdateShipCount()
+	if p.Construction != nil {
+		return nil, errors.New("Another building is under construction")
+	}
+
+	level := p.BuildingLevel(building) + 1
+	if level > settings.MaxLevel {
+		return nil, errors.New("The building is already at its maximum level")
+	}
+
+	cost := settings.LevelCost(level)
+	if p.ShipCount < cost {
+		return nil, errors.New("Not enough pilots on the planet")
+	}
+	if err := p.SpendEnergy(settings.LevelEnergyCost(level)); err != nil {
+		return nil, err
+	}
+
+	p.SetShipCount(p.ShipCount - cost)
+	p.Construction = &Construction{
+		Building:   building,
+		Level:      level,
+		FinishesAt: clock.Now().Add(settings.LevelBuildTime(level)).Unix(),
+	}
+	return p.Construction, nil
+}
+
+// Applies the finished construction on the planet.
+func (p *Planet) completeConstruction() {
+	if p.Buildings == nil {
+		p.Buildings = make(map[string]uint8)
+	}
+	p.Buildings[p.Construction.Building] = p.Construction.Level
+	p.Construction = nil
+	p.MaxShipCount = p.maxShipCount()
+}
+
+// Returns the population cap of the planet including its storage.
+func (p *Planet) maxShipCount() int32 {
+	base := p.world.PlanetProduction(p.Size, p.IsHome).MaxShipCount()
+	return int32(float64(base) * p.BuildingModifier(config.Storage))
+}
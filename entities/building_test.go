@@ -0,0 +1,118 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/config"
+)
+
+func newUpgradedPlanet(shipCount int32) *Planet {
+	return &Planet{
+		Name:                "GOP6723",
+		Position:            vec2d.New(2, 2),
+		Size:                3,
+		LastShipCountUpdate: time.Now().Unix(),
+		ShipCount:           shipCount,
+		MaxShipCount:        PlanetProduction(3, false).MaxShipCount(),
+		Owner:               "gophie",
+		Energy:              1000,
+	}
+}
+
+func TestStartUpgrade(t *testing.T) {
+	planet := newUpgradedPlanet(1000)
+	storage := Settings().Buildings[config.Storage]
+
+	construction, err := planet.StartUpgrade(config.Storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if construction.Level != 1 || construction.Building != config.Storage {
+		t.Errorf("Started building %s level %d", construction.Building, construction.Level)
+	}
+	if planet.ShipCount != 1000-storage.Cost {
+		t.Errorf("The planet has %d ships after the upgrade started", planet.ShipCount)
+	}
+	if planet.Energy != 1000-storage.EnergyCost {
+		t.Errorf("The planet has %f energy after the upgrade started", planet.Energy)
+	}
+	if planet.BuildingLevel(config.Storage) != 0 {
+		t.Error("The storage is ready before it is built")
+	}
+
+	if _, err := planet.StartUpgrade(config.Shipyard); err == nil {
+		t.Error("Two buildings are constructed at the same time")
+	}
+}
+
+func TestStartUpgradeFails(t *testing.T) {
+	planet := newUpgradedPlanet(1)
+	if _, err := planet.StartUpgrade(config.Radar); err == nil {
+		t.Error("Radar is built without enough pilots")
+	}
+	planet = newUpgradedPlanet(1000)
+	planet.Energy = 0
+	if _, err := planet.StartUpgrade(config.Radar); err == nil {
+		t.Error("Radar is built without enough energy")
+	}
+	if planet.ShipCount != 1000 {
+		t.Error("Ships are taken for an upgrade which hasn't started")
+	}
+	if _, err := planet.StartUpgrade("casino"); err == nil {
+		t.Error("Unknown building is built")
+	}
+
+	planet = newUpgradedPlanet(100000)
+	planet.Buildings = map[string]uint8{config.Radar: Settings().Buildings[config.Radar].MaxLevel}
+	if _, err := planet.StartUpgrade(config.Radar); err == nil {
+		t.Error("Radar is built above its maximum level")
+	}
+}
+
+func TestConstructionCompletes(t *testing.T) {
+	planet := newUpgradedPlanet(1000)
+	baseMaxShipCount := planet.MaxShipCount
+
+	planet.StartUpgrade(config.Storage)
+	planet.Construction.FinishesAt = time.Now().Unix() - 1
+	planet.UpdateShipCount()
+
+	if planet.Construction != nil {
+		t.Error("The construction is not finished")
+	}
+	if planet.BuildingLevel(config.Storage) != 1 {
+		t.Errorf("Storage is at level %d instead of 1", planet.BuildingLevel(config.Storage))
+	}
+	expected := int32(float64(baseMaxShipCount) * Settings().Buildings[config.Storage].Modifier(1))
+	if planet.MaxShipCount != expected {
+		t.Errorf("Max ship count is %d instead of %d", planet.MaxShipCount, expected)
+	}
+}
+
+func TestShipyardProduction(t *testing.T) {
+	planet := newUpgradedPlanet(0)
+	planet.Buildings = map[string]uint8{config.Shipyard: 4}
+	planet.LastShipCountUpdate = time.Now().Unix() - 270
+
+	// 27 seconds per ship and twice as fast with the shipyard
+	if ships := planet.GetShipCount(); ships != 20 {
+		t.Errorf("The planet produced %d ships instead of 20", ships)
+	}
+}
+
+func TestDefencesHoldAttack(t *testing.T) {
+	target := newUpgradedPlanet(100)
+	target.Owner = "chochko"
+	target.Buildings = map[string]uint8{config.Defences: 4}
+
+	attack := &Mission{Player: "gophie", ShipCount: 150, Type: "Attack"}
+	if _, ownerHasChanged := attack.EndAttackMission(target); ownerHasChanged {
+		t.Error("150 ships captured a planet defended with the strength of 200")
+	}
+	if target.ShipCount != 25 {
+		t.Errorf("The defenders are %d instead of 25", target.ShipCount)
+	}
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"warcluster/config"
+)
+
+// Rolls a random number in [0, 1) for every detection attempt.
+// Replaced in the tests to get predictable results.
+var detectionRoll = rand.Float64
+
+// Replaces the roll of the detection attempts with one of its own source
+// seeded with the given seed, so games with spies could be played again
+// the very same way. Returns a function which puts back the previous roll.
+func SeedDetectionRoll(seed int64) (restore func()) {
+	var mutex sync.Mutex
+	source := rand.New(rand.NewSource(seed))
+	previous := detectionRoll
+	detectionRoll = func() float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return source.Float64()
+	}
+	return func() { detectionRoll = previous }
+}
+
+// Returns the chance of the owner of the planet to detect spies above it
+// on every report. It grows with the ships on the planet and its radar.
+// Nobody watches the sky of neutral planets.
+func (p *Planet) SpyDetectionChance() float64 {
+	if p.Owner == "" {
+		return 0
+	}
+
+	settings := p.world.Settings()
+	chance := settings.SpyDetectionChance + float64(p.ShipCount)*settings.SpyDetectionPerShip
+	chance *= p.BuildingModifier(config.Radar)
+	return math.Min(chance, settings.SpyDetectionMaxChance)
+}
+
+// Gives the owner of the target a chance to detect the spies, before they
+// send their next report. Detected spies lose Settings().SpyDetectionKills
+// of their ships, at least one, and can't send more reports than the
+// ships they have left. Returns how many spies were killed.
+func (m *Mission) CounterIntelligence(target *Planet) int32 {
+	if target.Owner == m.Player || m.ShipCount <= 0 {
+		return 0
+	}
+	if detectionRoll() >= target.SpyDetectionChance() {
+		return 0
+	}
+
+	killed := int32(math.Ceil(float64(m.ShipCount) * m.world.Settings().SpyDetectionKills))
+	if killed > m.ShipCount {
+		killed = m.ShipCount
+	}
+	m.ShipCount -= killed
+	if m.ReportsLeft > m.ShipCount {
+		m.ReportsLeft = m.ShipCount
+	}
+	return killed
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+
+	"warcluster/config"
+)
+
+func TestSpyDetectionChance(t *testing.T) {
+	planet := newUpgradedPlanet(1000)
+	settings := Settings()
+
+	expected := settings.SpyDetectionChance + 1000*settings.SpyDetectionPerShip
+	if chance := planet.SpyDetectionChance(); chance != expected {
+		t.Errorf("Detection chance is %f instead of %f", chance, expected)
+	}
+
+	planet.Buildings = map[string]uint8{config.Radar: 2}
+	radar := settings.Buildings[config.Radar].Modifier(2)
+	if chance := planet.SpyDetectionChance(); chance != expected*radar {
+		t.Errorf("Detection chance with radar is %f instead of %f", chance, expected*radar)
+	}
+
+	planet.ShipCount = 1e9
+	if chance := planet.SpyDetectionChance(); chance != settings.SpyDetectionMaxChance {
+		t.Errorf("Detection chance is %f, above the maximum", chance)
+	}
+
+	planet.Owner = ""
+	if chance := planet.SpyDetectionChance(); chance != 0 {
+		t.Errorf("Neutral planet detects spies with chance %f", chance)
+	}
+}
+
+func TestCounterIntelligence(t *testing.T) {
+	defer func(roll func() float64) { detectionRoll = roll }(detectionRoll)
+	target := newUpgradedPlanet(1000)
+	target.Owner = "chochko"
+	spies := &Mission{Type: "Spy", Player: "gophie", ShipCount: 5, ReportsLeft: 5}
+
+	detectionRoll = func() float64 { return 0.99 }
+	if killed := spies.CounterIntelligence(target); killed != 0 || spies.ShipCount != 5 {
+		t.Errorf("Undetected spies lost %d ships", killed)
+	}
+
+	detectionRoll = func() float64 { return 0 }
+	if killed := spies.CounterIntelligence(target); killed != 3 {
+		t.Errorf("Detected spies lost %d ships instead of 3", killed)
+	}
+	if spies.ShipCount != 2 || spies.ReportsLeft != 2 {
+		t.Errorf("Spies have %d ships and %d reports left", spies.ShipCount, spies.ReportsLeft)
+	}
+
+	spies.CounterIntelligence(target)
+	spies.CounterIntelligence(target)
+	if spies.ShipCount != 0 || spies.ReportsLeft != 0 {
+		t.Errorf("Spies survived with %d ships", spies.ShipCount)
+	}
+
+	own := &Mission{Type: "Spy", Player: "chochko", ShipCount: 5, ReportsLeft: 5}
+	if killed := own.CounterIntelligence(target); killed != 0 {
+		t.Errorf("Own spies lost %d ships", killed)
+	}
+}
+
+func TestSeedDetectionRoll(t *testing.T) {
+	roll := func(seed int64) []float64 {
+		defer SeedDetectionRoll(seed)()
+		return []float64{detectionRoll(), detectionRoll(), detectionRoll()}
+	}
+	if first, second := roll(42), roll(42); !reflect.DeepEqual(first, second) {
+		t.Errorf("The same seed rolls %v and %v", first, second)
+	}
+}
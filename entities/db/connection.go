@@ -16,9 +16,14 @@ var Pool *redis.Pool
 // It creates the DB connection and stores it in the connection variable.
 func InitPool(host string, port uint16, database uint8) {
 	log.Print("Initializing database connection... ")
+	Pool = NewPool(host, port, database)
+}
+
+// Creates a pool of connections to the given database.
+func NewPool(host string, port uint16, database uint8) *redis.Pool {
 	serverAddr := fmt.Sprintf("%v:%v", host, port)
 
-	Pool = &redis.Pool{
+	return &redis.Pool{
 		MaxIdle:     3,
 		IdleTimeout: 240 * time.Second,
 		Dial: func() (redis.Conn, error) {
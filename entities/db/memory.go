@@ -0,0 +1,192 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// memoryStore keeps records and sets in memory. It knows only the Redis
+// commands used by the game, so games could be simulated without Redis.
+type memoryStore struct {
+	mutex   sync.Mutex
+	records map[string][]byte
+	sets    map[string]map[string]struct{}
+}
+
+// Replaces Pool with a pool of connections to a new, empty in-memory
+// database. It's meant for tests and simulations, so everything written
+// there is lost with the pool.
+func InitMemoryPool() {
+	Pool = NewMemoryPool()
+}
+
+// Creates a pool of connections to a new, empty in-memory database.
+func NewMemoryPool() *redis.Pool {
+	store := &memoryStore{
+		records: make(map[string][]byte),
+		sets:    make(map[string]map[string]struct{}),
+	}
+
+	return &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return &memoryConn{store: store}, nil
+		},
+	}
+}
+
+// memoryConn is a redis.Conn to a memoryStore.
+type memoryConn struct {
+	store   *memoryStore
+	replies []interface{}
+}
+
+func (c *memoryConn) Close() error {
+	return nil
+}
+
+func (c *memoryConn) Err() error {
+	return nil
+}
+
+func (c *memoryConn) Send(command string, args ...interface{}) error {
+	reply, err := c.Do(command, args...)
+	if err != nil {
+		return err
+	}
+	c.replies = append(c.replies, reply)
+	return nil
+}
+
+func (c *memoryConn) Flush() error {
+	return nil
+}
+
+func (c *memoryConn) Receive() (interface{}, error) {
+	if len(c.replies) == 0 {
+		return nil, errors.New("No reply pending")
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+func (c *memoryConn) Do(command string, args ...interface{}) (interface{}, error) {
+	s := c.store
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	arg := func(i int) string {
+		switch value := args[i].(type) {
+		case []byte:
+			return string(value)
+		case string:
+			return value
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+
+	switch strings.ToUpper(command) {
+	case "":
+		return nil, nil
+	case "PING":
+		return "PONG", nil
+	case "SELECT":
+		return "OK", nil
+	case "FLUSHDB":
+		s.records = make(map[string][]byte)
+		s.sets = make(map[string]map[string]struct{})
+		return "OK", nil
+	case "SET":
+		s.records[arg(0)] = []byte(arg(1))
+		return "OK", nil
+	case "GET":
+		if record, ok := s.records[arg(0)]; ok {
+			return record, nil
+		}
+		return nil, nil
+	case "DEL":
+		_, record := s.records[arg(0)]
+		_, set := s.sets[arg(0)]
+		delete(s.records, arg(0))
+		delete(s.sets, arg(0))
+		if record || set {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "KEYS":
+		var keys []string
+		for key := range s.records {
+			keys = append(keys, key)
+		}
+		for key := range s.sets {
+			keys = append(keys, key)
+		}
+		return s.match(keys, arg(0)), nil
+	case "SADD":
+		return s.add(arg(0), arg(1)), nil
+	case "SREM":
+		return s.remove(arg(0), arg(1)), nil
+	case "SMOVE":
+		if s.remove(arg(0), arg(2)) == 0 {
+			return int64(0), nil
+		}
+		s.add(arg(1), arg(2))
+		return int64(1), nil
+	case "SISMEMBER":
+		if _, ok := s.sets[arg(0)][arg(1)]; ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "SMEMBERS":
+		var members []string
+		for member := range s.sets[arg(0)] {
+			members = append(members, member)
+		}
+		return s.match(members, "*"), nil
+	}
+	return nil, fmt.Errorf("%s is not supported in memory", command)
+}
+
+// Returns the values matching the Redis pattern as a multi bulk reply.
+func (s *memoryStore) match(values []string, pattern string) []interface{} {
+	sort.Strings(values)
+	result := []interface{}{}
+	for _, value := range values {
+		if ok, _ := path.Match(pattern, value); ok {
+			result = append(result, []byte(value))
+		}
+	}
+	return result
+}
+
+func (s *memoryStore) add(set, member string) int64 {
+	if s.sets[set] == nil {
+		s.sets[set] = make(map[string]struct{})
+	}
+	if _, ok := s.sets[set][member]; ok {
+		return 0
+	}
+	s.sets[set][member] = struct{}{}
+	return 1
+}
+
+func (s *memoryStore) remove(set, member string) int64 {
+	if _, ok := s.sets[set][member]; !ok {
+		return 0
+	}
+	delete(s.sets[set], member)
+	if len(s.sets[set]) == 0 {
+		delete(s.sets, set)
+	}
+	return 1
+}
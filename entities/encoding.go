@@ -0,0 +1,157 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"strings"
+)
+
+// Every record is stored in an envelope: a magic header followed by the
+// schema version of the entity type and the gob encoded entity itself.
+// Records written before envelopes existed have no header and are treated
+// as version 0.
+var envelopeMagic = []byte("WCE")
+
+const envelopeHeaderSize = 5 // len(envelopeMagic) + uint16 version
+
+// Upgrade brings an entity decoded from a record of an older schema version
+// to the next one. It is registered for the version it upgrades from.
+type Upgrade func(Entity) error
+
+type schema struct {
+	version  uint16
+	upgrades map[uint16]Upgrade
+}
+
+// Current schema version of each entity type, keyed by the key prefix.
+// Bump the version and register an Upgrade from the previous one
+// whenever a change in the struct needs existing records to be fixed.
+var schemas = map[string]*schema{
+	"player":     {version: 1},
+	"planet":     {version: 1},
+	"mission":    {version: 1},
+	"sun":        {version: 1},
+	"ss":         {version: 1},
+	"spy_report": {version: 1},
+	"history":    {version: 1},
+	"tournament": {version: 1},
+	"season":     {version: 1},
+}
+
+func init() {
+	// Version 1 only wrapped the very same gob payload in an envelope
+	for entityType := range schemas {
+		RegisterUpgrade(entityType, 0, func(Entity) error { return nil })
+	}
+}
+
+// RegisterUpgrade adds an upgrade of the given entity type from version `from`
+// to `from + 1`. It panics on unknown entity types, because that's clearly a
+// programming error.
+func RegisterUpgrade(entityType string, from uint16, upgrade Upgrade) {
+	s, ok := schemas[entityType]
+	if !ok {
+		panic(fmt.Sprintf("Unknown entity type %q", entityType))
+	}
+	if s.upgrades == nil {
+		s.upgrades = make(map[uint16]Upgrade)
+	}
+	s.upgrades[from] = upgrade
+}
+
+// Returns the current schema version of the given entity type.
+func SchemaVersion(entityType string) uint16 {
+	if s, ok := schemas[entityType]; ok {
+		return s.version
+	}
+	return 0
+}
+
+// Returns the entity type of a database key. It is the part before the first dot.
+func entityType(key string) string {
+	return strings.SplitN(key, ".", 2)[0]
+}
+
+// Creates a new empty entity of the given type
+func newEntity(entityType string) (Entity, error) {
+	switch entityType {
+	case "player":
+		return new(Player), nil
+	case "planet":
+		return new(Planet), nil
+	case "mission":
+		return new(Mission), nil
+	case "sun":
+		return new(Sun), nil
+	case "ss":
+		return new(SolarSlot), nil
+	case "spy_report":
+		return new(SpyReport), nil
+	case "history":
+		return new(HistoryEntry), nil
+	case "tournament":
+		return new(TournamentResult), nil
+	case "season":
+		return new(Season), nil
+	}
+	return nil, fmt.Errorf("Unknown entity type %q", entityType)
+}
+
+// Marshals the entity and wraps it in an envelope with its current schema version.
+func encode(entity Entity) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	buffer.Write(envelopeMagic)
+	binary.Write(&buffer, binary.BigEndian, SchemaVersion(entityType(entity.Key())))
+	if err := gob.NewEncoder(&buffer).Encode(entity); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Splits a record into its schema version and gob payload.
+func openEnvelope(data []byte) (uint16, []byte) {
+	if len(data) < envelopeHeaderSize || !bytes.HasPrefix(data, envelopeMagic) {
+		return 0, data
+	}
+	return binary.BigEndian.Uint16(data[len(envelopeMagic):]), data[envelopeHeaderSize:]
+}
+
+// Unmarshals a record and runs all upgrades needed to bring it to the
+// current schema version of its type.
+func decode(key string, data []byte) (Entity, error) {
+	entityType := entityType(key)
+	entity, err := newEntity(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	version, payload := openEnvelope(data)
+	s := schemas[entityType]
+	if version > s.version {
+		return nil, fmt.Errorf("%s has schema version %d, newer than %d", key, version, s.version)
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(entity); err != nil {
+		return nil, fmt.Errorf("Decoding %s: %s", key, err)
+	}
+
+	for ; version < s.version; version++ {
+		upgrade, ok := s.upgrades[version]
+		if !ok {
+			return nil, fmt.Errorf("No upgrade of %s from version %d", entityType, version)
+		}
+		if err := upgrade(entity); err != nil {
+			return nil, fmt.Errorf("Upgrading %s from version %d: %s", key, version, err)
+		}
+	}
+	return entity, nil
+}
+
+// Returns the schema version of a raw record.
+func RecordVersion(data []byte) uint16 {
+	version, _ := openEnvelope(data)
+	return version
+}
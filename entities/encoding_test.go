@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	record, err := encode(&sun)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version := RecordVersion(record); version != SchemaVersion("sun") {
+		t.Errorf("Record version is %d instead of %d", version, SchemaVersion("sun"))
+	}
+
+	entity, err := decode(sun.Key(), record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded := entity.(*Sun); decoded.Name != sun.Name || decoded.Username != sun.Username {
+		t.Errorf("Decoded sun is %#v", decoded)
+	}
+}
+
+func TestDecodeLegacyRecord(t *testing.T) {
+	var buffer bytes.Buffer
+	gob.NewEncoder(&buffer).Encode(&sun)
+
+	if version := RecordVersion(buffer.Bytes()); version != 0 {
+		t.Errorf("Legacy record has version %d", version)
+	}
+
+	entity, err := decode(sun.Key(), buffer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.(*Sun).Name != sun.Name {
+		t.Errorf("Decoded legacy sun is %#v", entity)
+	}
+}
+
+func TestDecodeRunsUpgrades(t *testing.T) {
+	defer func(s schema) {
+		*schemas["sun"] = s
+	}(*schemas["sun"])
+
+	record, _ := encode(&sun)
+	schemas["sun"] = &schema{version: 3, upgrades: map[uint16]Upgrade{}}
+	RegisterUpgrade("sun", 1, func(e Entity) error {
+		e.(*Sun).SunTextureId = 2
+		return nil
+	})
+	RegisterUpgrade("sun", 2, func(e Entity) error {
+		e.(*Sun).SunTextureId *= 2
+		return nil
+	})
+
+	entity, err := decode(sun.Key(), record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texture := entity.(*Sun).SunTextureId; texture != 4 {
+		t.Errorf("Upgraded sun has texture %d instead of 4", texture)
+	}
+}
+
+func TestDecodeFailures(t *testing.T) {
+	defer func(s schema) {
+		*schemas["sun"] = s
+	}(*schemas["sun"])
+
+	record, _ := encode(&sun)
+	if _, err := decode(sun.Key(), record[:len(record)-3]); err == nil {
+		t.Error("Truncated record has been decoded")
+	}
+	if _, err := decode("panda.GOP672", record); err == nil {
+		t.Error("Record of unknown type has been decoded")
+	}
+
+	schemas["sun"].version = 0
+	if _, err := decode(sun.Key(), record); err == nil {
+		t.Error("Record newer than the schema has been decoded")
+	}
+
+	schemas["sun"].version = 2
+	if _, err := decode(sun.Key(), record); err == nil {
+		t.Error("Record has been decoded without an upgrade")
+	}
+
+	schemas["sun"].upgrades = map[uint16]Upgrade{
+		1: func(Entity) error { return errors.New("Nope") },
+	}
+	if _, err := decode(sun.Key(), record); err == nil {
+		t.Error("Record has been decoded despite the failed upgrade")
+	}
+}
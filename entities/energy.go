@@ -0,0 +1,40 @@
+package entities
+
+import "errors"
+
+// Takes the given amount of energy from the planet.
+// Nothing is taken if the planet doesn't have enough.
+func (p *Planet) SpendEnergy(amount float64) error {
+	p.UpdateShipCount()
+	if p.Energy < amount {
+		return errors.New("Not enough energy on the planet")
+	}
+	p.Energy -= amount
+	return nil
+}
+
+// Returns how much energy the source planet of a mission has to spend.
+// Spying costs energy and so does boosting any kind of mission.
+func MissionEnergyCost(missionType string, boost bool) float64 {
+	return defaultWorld.MissionEnergyCost(missionType, boost)
+}
+
+// Returns how much energy the source planet of a mission in the world
+// has to spend.
+func (w *World) MissionEnergyCost(missionType string, boost bool) float64 {
+	var cost float64
+	if missionType == "Spy" {
+		cost += w.Settings().SpyEnergy
+	}
+	if boost {
+		cost += w.Settings().MissionBoostEnergy
+	}
+	return cost
+}
+
+// Makes the mission faster. It has to be called before the mission starts
+// traveling, because its travel time is recalculated.
+func (m *Mission) Boost() {
+	m.Boosted = true
+	m.updateSpeed()
+}
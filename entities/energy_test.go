@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+)
+
+func TestEnergyProduction(t *testing.T) {
+	planet := newUpgradedPlanet(0)
+	planet.Energy = 0
+	planet.LastShipCountUpdate = time.Now().Unix() - 120
+
+	expected := 2 * PlanetProduction(planet.Size, false).EnergyPerMinute
+	if planet.UpdateShipCount(); planet.Energy != expected {
+		t.Errorf("The planet gathered %f energy instead of %f", planet.Energy, expected)
+	}
+
+	if err := planet.SpendEnergy(expected + 1); err == nil {
+		t.Error("Spent more energy than the planet has")
+	}
+	if err := planet.SpendEnergy(expected); err != nil || planet.Energy != 0 {
+		t.Errorf("Spending all the energy failed with %v, %f energy left", err, planet.Energy)
+	}
+}
+
+func TestEnergyIsHidden(t *testing.T) {
+	planet := newUpgradedPlanet(0)
+
+	if packet := planet.Sanitize(&Player{Username: "chochko"}); packet.Energy != -1 {
+		t.Errorf("Strangers see %f energy", packet.Energy)
+	}
+	if packet := planet.Sanitize(&Player{Username: "gophie"}); packet.Energy != planet.Energy {
+		t.Errorf("The owner sees %f energy instead of %f", packet.Energy, planet.Energy)
+	}
+}
+
+func TestMissionEnergyCost(t *testing.T) {
+	if cost := MissionEnergyCost("Attack", false); cost != 0 {
+		t.Errorf("Regular attack costs %f energy", cost)
+	}
+	expected := Settings().SpyEnergy + Settings().MissionBoostEnergy
+	if cost := MissionEnergyCost("Spy", true); cost != expected {
+		t.Errorf("Boosted spying costs %f energy instead of %f", cost, expected)
+	}
+}
+
+func TestBoost(t *testing.T) {
+	source := newUpgradedPlanet(100)
+	target := newUpgradedPlanet(0)
+	target.Position = vec2d.New(20002, 2)
+
+	mission := new(Player).StartMission(source, target, nil, 100, "Attack")
+	travelTime := mission.TravelTime
+	mission.Boost()
+
+	expected := time.Duration(float64(travelTime) / Settings().MissionBoostSpeed)
+	if mission.TravelTime != expected {
+		t.Errorf("Boosted mission travels %v instead of %v", mission.TravelTime, expected)
+	}
+}
@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+
+	"warcluster/clock"
+)
+
+// Events recorded in the history of the players.
+const (
+	// The owner of the planet caught spies above it
+	SpiesDetected = "spies_detected"
+	// The spies of the player were caught above an enemy planet
+	SpiesCaught = "spies_caught"
+	// The home planet of the player was taken
+	HomePlanetLost = "home_planet_lost"
+	// The player took the home planet of another one
+	HomePlanetTaken = "home_planet_taken"
+)
+
+// HistoryEntry is a single event in the history of a player.
+// Planet is where it happened and Other is the other player involved,
+// if any. CreatedAt is in ms, so two events in the same second do not
+// overwrite each other.
+type HistoryEntry struct {
+	Player    string
+	Event     string
+	Planet    string
+	Other     string
+	Ships     int32
+	CreatedAt int64
+}
+
+// Database key.
+func (h *HistoryEntry) Key() string {
+	return fmt.Sprintf("history.%s_%d", h.Player, h.CreatedAt)
+}
+
+// It has to be there in order to implement Entity
+func (h *HistoryEntry) AreaSet() string {
+	return ""
+}
+
+// Saves a new event in the history of the player. Only the latest
+// Settings().HistorySize events of every player are kept.
+func RecordHistory(player, event, planet, other string, ships int32) *HistoryEntry {
+	return defaultWorld.RecordHistory(player, event, planet, other, ships)
+}
+
+// Saves a new event in the history of the player in the world.
+func (w *World) RecordHistory(player, event, planet, other string, ships int32) *HistoryEntry {
+	entry := &HistoryEntry{
+		Player:    player,
+		Event:     event,
+		Planet:    planet,
+		Other:     other,
+		Ships:     ships,
+		CreatedAt: clock.NowMs(),
+	}
+	w.Save(entry)
+
+	history := w.PlayerHistory(player)
+	for i := w.Settings().HistorySize; i < len(history); i++ {
+		w.Delete(history[i].Key())
+	}
+	return entry
+}
+
+// Returns the history of the player, latest events first.
+func PlayerHistory(player string) []*HistoryEntry {
+	return defaultWorld.PlayerHistory(player)
+}
+
+// Returns the history of the player in the world, latest events first.
+func (w *World) PlayerHistory(player string) []*HistoryEntry {
+	var history []*HistoryEntry
+	for _, entity := range w.Find(fmt.Sprintf("history.%s_*", player)) {
+		entry := entity.(*HistoryEntry)
+		// The pattern matches players with the same prefix as well
+		if entry.Player == player {
+			history = append(history, entry)
+		}
+	}
+
+	sort.Sort(latestFirst(history))
+	return history
+}
+
+// Sorts history entries, latest first
+type latestFirst []*HistoryEntry
+
+func (h latestFirst) Len() int           { return len(h) }
+func (h latestFirst) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h latestFirst) Less(i, j int) bool { return h[i].CreatedAt > h[j].CreatedAt }
@@ -1,11 +1,22 @@
 package entities
 
 import (
+	"log"
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/config"
 )
 
+func init() {
+	var cfg config.Config
+	if err := cfg.Load(); err != nil {
+		log.Fatal(err)
+	}
+	ExportConfig(cfg)
+}
+
 var (
 	timeStamp int64   = time.Date(2012, time.November, 10, 23, 0, 0, 0, time.UTC).UnixNano() / 1e6
 	now       int64   = time.Now().UnixNano() * 1e6
@@ -2,10 +2,8 @@
 package entities
 
 import (
-	"bytes"
-	"encoding/gob"
 	"errors"
-	"strings"
+	"log"
 
 	"warcluster/config"
 	"warcluster/entities/db"
@@ -30,16 +28,13 @@ type Color struct {
 	B float32
 }
 
-var (
-	Settings config.Entities
-	Races    []Race
-)
-
-func init() {
-	var cfg config.Config
-	cfg.Load()
+var Races []Race
 
-	Settings = cfg.Entities
+// Exports the entities related part of the given config into Settings
+// and builds the list of races out of it. Nothing is loaded on its own,
+// so it has to be called before anything else in the package is used.
+func ExportConfig(cfg config.Config) {
+	setSettings(cfg.Entities)
 	Races = make([]Race, len(cfg.Race), len(cfg.Race))
 	for name, params := range cfg.Race {
 		Races[params.Id] = Race{params.Id, name, Color{params.Red, params.Green, params.Blue}}
@@ -60,66 +55,100 @@ func (c *Color) Validate() error {
 	return nil
 }
 
-// Creates an entity via unmarshaling a json.
-// The concrete entity type is given by the user as `key`
-func Load(key string, data []byte) Entity {
-	var (
-		buffer bytes.Buffer
-		entity Entity
-	)
-
-	buffer.Write(data)
-	decoder := gob.NewDecoder(&buffer)
-	entityType := strings.Split(key, ".")[0]
-
-	switch entityType {
-	case "player":
-		entity = new(Player)
-	case "planet":
-		entity = new(Planet)
-	case "mission":
-		entity = new(Mission)
-	case "sun":
-		entity = new(Sun)
-	case "ss":
-		entity = new(SolarSlot)
-	case "spy_report":
-		entity = new(SpyReport)
-	default:
-		return nil
-	}
-	decoder.Decode(entity)
-	return entity
+// Creates an entity via unmarshaling a database record.
+// The concrete entity type is given by the user as `key`.
+// Records of older schema versions are upgraded on the fly.
+func Load(key string, data []byte) (Entity, error) {
+	return defaultWorld.Load(key, data)
 }
 
 // Finds records in the database, by given key
 // All Redis wildcards are allowed.
 func Find(query string) []Entity {
+	return defaultWorld.Find(query)
+}
+
+// Returns keys of entities from the database
+func GetList(pattern string) ([]string, error) {
+	return defaultWorld.GetList(pattern)
+}
+
+// Fetches a single record in the database, by given concrete key.
+// If there is no entity with such key, returns error.
+func Get(key string) (Entity, error) {
+	return defaultWorld.Get(key)
+}
+
+// Saves an entity to the database. Records' key is entity.Key()
+// If there is a record with such key in the database, simply updates
+// the record. Otherwise creates a new one.
+func Save(entity Entity) error {
+	return defaultWorld.Save(entity)
+}
+
+// Deletes a record by the given key
+func Delete(key string) error {
+	return defaultWorld.Delete(key)
+}
+
+// Get and serialize all members of a set
+func GetAreasMembers(areas []string) []Entity {
+	return defaultWorld.GetAreasMembers(areas)
+}
+
+// Remove a member from set
+func RemoveFromArea(key, set string) error {
+	return defaultWorld.RemoveFromArea(key, set)
+}
+
+// Returns if entity is a member of the set
+func InArea(key, set string) bool {
+	return defaultWorld.InArea(key, set)
+}
+
+// Creates an entity via unmarshaling a database record of the world.
+func (w *World) Load(key string, data []byte) (Entity, error) {
+	entity, err := decode(key, data)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := entity.(worldEntity); ok {
+		e.setWorld(w)
+	}
+	return entity, nil
+}
+
+// Finds records in the database of the world, by given key
+// All Redis wildcards are allowed.
+func (w *World) Find(query string) []Entity {
 	var entityList []Entity
 
-	if records, err := GetList(query); err == nil {
+	if records, err := w.GetList(query); err == nil {
 		for _, key := range records {
-			if entity, err := Get(key); err == nil {
-				entityList = append(entityList, entity)
+			entity, err := w.Get(key)
+			if err != nil {
+				log.Printf("Can't load %s: %s", key, err)
+				continue
 			}
+			entityList = append(entityList, entity)
 		}
 	}
 
 	return entityList
 }
 
-// Returns keys of entities from the database
-func GetList(pattern string) ([]string, error) {
-	conn := db.Pool.Get()
+// Returns keys of entities from the database of the world
+func (w *World) GetList(pattern string) ([]string, error) {
+	conn := w.Conn()
 	defer conn.Close()
 
 	return db.GetList(conn, pattern)
 }
 
-// Fetches a single record in the database, by given concrete key.
-// If there is no entity with such key, returns error.
-func Get(key string) (Entity, error) {
-	conn := db.Pool.Get()
+// Fetches a single record in the database of the world, by given concrete
+// key. If there is no entity with such key, returns error.
+func (w *World) Get(key string) (Entity, error) {
+	conn := w.Conn()
 	defer conn.Close()
 
 	record, err := db.Get(conn, key)
@@ -127,44 +156,41 @@ func Get(key string) (Entity, error) {
 		return nil, err
 	}
 
-	return Load(key, record), nil
+	return w.Load(key, record)
 }
 
-// Saves an entity to the database. Records' key is entity.Key()
+// Saves an entity to the database of the world. Records' key is entity.Key()
 // If there is a record with such key in the database, simply updates
 // the record. Otherwise creates a new one.
 //
 // Failed marshaling of the given entity is pretty much the only
 // point of failure in this function... I supose.
-func Save(entity Entity) error {
-	var buffer bytes.Buffer
-
-	encoder := gob.NewEncoder(&buffer)
+func (w *World) Save(entity Entity) error {
 	key := entity.Key()
-	err := encoder.Encode(entity)
+	record, err := encode(entity)
 	if err != nil {
 		return err
 	}
 
 	setKey := entity.AreaSet()
 
-	conn := db.Pool.Get()
+	conn := w.Conn()
 	defer conn.Close()
 
-	return db.Save(conn, key, setKey, buffer.Bytes())
+	return db.Save(conn, key, setKey, record)
 }
 
-// Deletes a record by the given key
-func Delete(key string) error {
-	conn := db.Pool.Get()
+// Deletes a record of the world by the given key
+func (w *World) Delete(key string) error {
+	conn := w.Conn()
 	defer conn.Close()
 
 	return db.Delete(conn, key)
 }
 
-// Get and serialize all members of a set
-func GetAreasMembers(areas []string) []Entity {
-	conn := db.Pool.Get()
+// Get and serialize all members of a set in the world
+func (w *World) GetAreasMembers(areas []string) []Entity {
+	conn := w.Conn()
 	defer conn.Close()
 
 	keys := []string{}
@@ -184,31 +210,36 @@ func GetAreasMembers(areas []string) []Entity {
 			continue
 		}
 
-		entityList = append(entityList, Load(key, record))
+		entity, err := w.Load(key, record)
+		if err != nil {
+			log.Printf("Can't load %s: %s", key, err)
+			continue
+		}
+		entityList = append(entityList, entity)
 	}
 
 	return entityList
 }
 
 // Move member from one set to another
-func moveToArea(key, from, to string) error {
-	conn := db.Pool.Get()
+func (w *World) moveToArea(key, from, to string) error {
+	conn := w.Conn()
 	defer conn.Close()
 
 	return db.Smove(conn, from, to, key)
 }
 
 // Remove a member from set
-func RemoveFromArea(key, set string) error {
-	conn := db.Pool.Get()
+func (w *World) RemoveFromArea(key, set string) error {
+	conn := w.Conn()
 	defer conn.Close()
 
 	return db.Srem(conn, set, key)
 }
 
 // Returns if entity is a member of the set
-func InArea(key, set string) bool {
-	conn := db.Pool.Get()
+func (w *World) InArea(key, set string) bool {
+	conn := w.Conn()
 	defer conn.Close()
 
 	result, err := db.Sismember(conn, set, key)
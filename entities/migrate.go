@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"fmt"
+
+	"warcluster/entities/db"
+)
+
+// Migrate rewrites every record stored with an older schema version
+// using the current one. Records are written in place, without touching
+// the area sets they are members of.
+//
+// Returns the count of migrated records and all errors faced on the way.
+// A failed record is left as it is, so it could be fixed and migrated again.
+func Migrate() (migrated int, errs []error) {
+	return defaultWorld.Migrate()
+}
+
+// Migrates the records in the database of the world, just like Migrate.
+func (w *World) Migrate() (migrated int, errs []error) {
+	conn := w.Conn()
+	defer conn.Close()
+
+	for entityType := range schemas {
+		keys, err := db.GetList(conn, fmt.Sprintf("%s.*", entityType))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, key := range keys {
+			record, err := db.Get(conn, key)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Fetching %s: %s", key, err))
+				continue
+			}
+			if RecordVersion(record) == SchemaVersion(entityType) {
+				continue
+			}
+
+			entity, err := w.Load(key, record)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if record, err = encode(entity); err == nil {
+				err = db.Save(conn, key, "", record)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Saving %s: %s", key, err))
+				continue
+			}
+			migrated++
+		}
+	}
+	return
+}
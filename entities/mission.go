@@ -2,12 +2,15 @@ package entities
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/config"
 )
 
 type Mission struct {
@@ -20,7 +23,19 @@ type Mission struct {
 	TravelTime time.Duration // in ms.
 	Player     string
 	ShipCount  int32
+	Speed      float64
+	Boosted    bool `json:",omitempty"`
 	areaSet    string
+
+	// Validity of the spy reports in seconds. It depends on the radar of
+	// the source planet, so it is fixed when the mission starts.
+	SpyReportValidity time.Duration `json:",omitempty"`
+
+	// Spy station settings, see Station.
+	ReportInterval time.Duration `json:",omitempty"` // in seconds
+	ReportsLeft    int32         `json:",omitempty"`
+
+	world *World
 }
 
 // Just an internal type, used to embed source and target in Mission
@@ -35,6 +50,10 @@ func (m *Mission) Key() string {
 	return fmt.Sprintf("mission.%d_%s", m.StartTime, m.Source.Name)
 }
 
+func (m *Mission) setWorld(w *World) {
+	m.world = w
+}
+
 // Returns the sorted set by X or Y where this entity has to be put in
 func (m *Mission) AreaSet() string {
 	return m.areaSet
@@ -47,7 +66,25 @@ func (m *Mission) SetAreaSet(value string) {
 
 // Changes its areaset based on axis and direction and updates the db
 func (m *Mission) ChangeAreaSet(axis rune, direction int8) {
-	areaParts := strings.Split(m.areaSet, ":")
+	oldAreaSet := m.areaSet
+	m.areaSet = nextAreaSet(m.areaSet, axis, direction)
+	m.world.moveToArea(m.Key(), oldAreaSet, m.areaSet)
+}
+
+// Returns all areas the mission is going to travel through in order,
+// starting with the one it is in right now.
+func (m *Mission) Areas() []string {
+	areas := []string{m.areaSet}
+	for _, transferPoint := range m.TransferPoints() {
+		areas = append(areas, nextAreaSet(areas[len(areas)-1], transferPoint.CoordinateAxis, transferPoint.Direction))
+	}
+	return areas
+}
+
+// Returns the area next to the given one in the given direction.
+// There is no area 0 on neither axis.
+func nextAreaSet(areaSet string, axis rune, direction int8) string {
+	areaParts := strings.Split(areaSet, ":")
 	x, _ := strconv.ParseInt(areaParts[1], 10, 64)
 	y, _ := strconv.ParseInt(areaParts[2], 10, 64)
 
@@ -62,109 +99,143 @@ func (m *Mission) ChangeAreaSet(axis rune, direction int8) {
 			y += int64(direction)
 		}
 	}
-
-	oldAreaSet := m.areaSet
-	m.areaSet = fmt.Sprintf("area:%d:%d", x, y)
-	moveToArea(m.Key(), oldAreaSet, m.areaSet)
+	return fmt.Sprintf("area:%d:%d", x, y)
 }
 
-func fillAxises(startPoint, endPoint float64) (container []int64) {
-	startAxis := RoundCoordinateTo(startPoint)
-	endAxis := RoundCoordinateTo(endPoint)
-	axises := []int64{startAxis, endAxis}
-	if endAxis < startAxis {
-		axises = []int64{endAxis, startAxis}
-	}
-
-	for i := axises[0] + 1; i < axises[1]; i += 1 {
-		container = append(container, i*Settings.AreaSize)
-	}
-	return
-}
-
-func axisDirection(xA, xB float64) int8 {
-	if xB > xA {
-		return 1
-	} else if xB == xA {
-		return 0
+// Returns the coordinates of all area borders crossed on the way from start
+// to end on a single axis. Areas are [(k-1)*size, k*size) (see
+// RoundCoordinateTo), so a border is crossed when a mission reaches it on
+// its way up or when it leaves it on its way down.
+func areaBorders(start, end float64) []float64 {
+	var borders []float64
+	size := float64(Settings().AreaSize)
+
+	if end > start {
+		for border := math.Floor(start/size)*size + size; border <= end; border += size {
+			borders = append(borders, border)
+		}
 	} else {
-		return -1
+		for border := math.Floor(start/size) * size; border > end; border -= size {
+			borders = append(borders, border)
+		}
 	}
+	return borders
 }
 
-// Returns all transfer points this mission will ever cross
+// Returns all transfer points this mission will ever cross. The travel time
+// of each point is counted from the previous one (or from the start of the
+// mission), so the missionary could simply sleep from one to another. They
+// are calculated with the speed of the mission, just like its TravelTime.
+// The missionary moves the mission to the next area at each of them.
 func (m *Mission) TransferPoints() AreaTransferPoints {
+	var traveled float64
 	result := make(AreaTransferPoints, 0, 10)
 
-	calculateSegmentTransfers := func(source, target *vec2d.Vector) AreaTransferPoints {
-		result := make(AreaTransferPoints, 0, 10)
-		var baseTravelTime time.Duration
-
-		xAxises := fillAxises(source.X, target.X)
-		yAxises := fillAxises(source.Y, target.Y)
-
-		missionVectorEquation := NewCartesianEquation(source, target)
-
-		direction := []int8{
-			axisDirection(source.X, target.X),
-			axisDirection(source.Y, target.Y),
+	addSegmentTransfers := func(source, target *vec2d.Vector) {
+		length := vec2d.GetDistance(source, target)
+		axes := []struct {
+			name       rune
+			start, end float64
+		}{
+			{'X', source.X, target.X},
+			{'Y', source.Y, target.Y},
 		}
 
-		for _, axis := range xAxises {
-			crossPoint := vec2d.New(float64(axis), missionVectorEquation.GetYByX(float64(axis)))
-			transferPoint := &AreaTransferPoint{
-				TravelTime:     calculateSegmentTravelTime(source, crossPoint, Settings.MissionSpeed),
-				Direction:      direction[0],
-				CoordinateAxis: 'X',
+		for _, axis := range axes {
+			direction := int8(1)
+			if axis.end < axis.start {
+				direction = -1
 			}
-			result.Append(transferPoint)
-		}
-
-		for _, axis := range yAxises {
-			crossPoint := vec2d.New(missionVectorEquation.GetXByY(float64(axis)), float64(axis))
-			transferPoint := &AreaTransferPoint{
-				TravelTime:     calculateSegmentTravelTime(source, crossPoint, Settings.MissionSpeed),
-				Direction:      direction[1],
-				CoordinateAxis: 'Y',
-			}
-			result.Append(transferPoint)
-		}
-		sort.Sort(result)
-
-		if result.Size() >= 1 {
-			baseTravelTime = result[0].TravelTime
-			for idx, point := range result {
-				if idx != 0 {
-					point.TravelTime -= baseTravelTime
-					baseTravelTime += point.TravelTime
-				}
+			for _, border := range areaBorders(axis.start, axis.end) {
+				distance := traveled + length*(border-axis.start)/(axis.end-axis.start)
+				result.Append(&AreaTransferPoint{
+					TravelTime:     time.Duration(distance / m.speed() * 100),
+					Direction:      direction,
+					CoordinateAxis: axis.name,
+				})
 			}
 		}
-		return result
+		traveled += length
 	}
 
 	prevWaypoint := m.Source.Position
-
 	for _, waypoint := range m.Path {
-		result.Append(calculateSegmentTransfers(prevWaypoint, waypoint)...)
+		addSegmentTransfers(prevWaypoint, waypoint)
 		prevWaypoint = waypoint
 	}
+	addSegmentTransfers(prevWaypoint, m.Target.Position)
 
-	result.Append(calculateSegmentTransfers(prevWaypoint, m.Target.Position)...)
-
+	sort.Stable(result)
+	var previous time.Duration
+	for _, point := range result {
+		point.TravelTime, previous = point.TravelTime-previous, point.TravelTime
+	}
 	return result
 }
 
+// Returns the speed of a mission with the given type and fleet size.
+// Types without a speed curve travel with the default mission speed.
+func MissionSpeed(missionType string, ships int32, boosted bool) float64 {
+	return defaultWorld.MissionSpeed(missionType, ships, boosted)
+}
+
+// Returns the speed of a mission with the given type and fleet size in
+// the world.
+func (w *World) MissionSpeed(missionType string, ships int32, boosted bool) float64 {
+	settings := w.Settings()
+	speed := float64(settings.MissionSpeed)
+	if curve, ok := settings.Speeds[missionType]; ok {
+		speed = curve.For(ships)
+	}
+	if boosted {
+		speed *= settings.MissionBoostSpeed
+	}
+	return speed
+}
+
+// Sets the speed of the mission by its type, fleet and boost and
+// recalculates its travel time. It has to be called before the mission
+// starts traveling.
+func (m *Mission) updateSpeed() {
+	m.Speed = m.world.MissionSpeed(m.Type, m.ShipCount, m.Boosted)
+	m.TravelTime = calculateMissionTravelTime(m.Source.Position, m.Target.Position, m.Path, m.Speed)
+}
+
+// Changes the fleet of a mission which hasn't started traveling yet.
+// Its speed depends on the fleet, so it is updated as well.
+func (m *Mission) SetShipCount(count int32) {
+	m.ShipCount = count
+	m.updateSpeed()
+}
+
+// Returns the speed of the mission. Missions started before the speed
+// was stored travel with the default one.
+func (m *Mission) speed() float64 {
+	if m.Speed > 0 {
+		return m.Speed
+	}
+	return float64(m.world.Settings().MissionSpeed)
+}
+
+// Returns for how long the spy reports of this mission are valid in seconds.
+// Missions started before radars existed use the default validity.
+func (m *Mission) ReportValidity() time.Duration {
+	if m.SpyReportValidity > 0 {
+		return m.SpyReportValidity
+	}
+	return m.world.Settings().SpyReportValidity
+}
+
 // Calculates the travel time in milliseconds between two points with given speed.
 // Traveling is implemented like a simple time.Sleep from our side.
-func calculateSegmentTravelTime(source, target *vec2d.Vector, speed int64) time.Duration {
+func calculateSegmentTravelTime(source, target *vec2d.Vector, speed float64) time.Duration {
 	distance := vec2d.GetDistance(source, target)
-	return time.Duration(distance / float64(speed) * 100)
+	return time.Duration(distance / speed * 100)
 }
 
 // Calculates the travel time in milliseconds between two points with given speed.
 // Traveling is implemented like a simple time.Sleep from our side.
-func calculateMissionTravelTime(source, target *vec2d.Vector, waypoints []*vec2d.Vector, speed int64) time.Duration {
+func calculateMissionTravelTime(source, target *vec2d.Vector, waypoints []*vec2d.Vector, speed float64) time.Duration {
 	var distance float64
 	prevPoint := source
 	distance = 0
@@ -173,7 +244,7 @@ func calculateMissionTravelTime(source, target *vec2d.Vector, waypoints []*vec2d
 		prevPoint = point
 	}
 	distance += vec2d.GetDistance(prevPoint, target)
-	return time.Duration(distance / float64(speed) * 100)
+	return time.Duration(distance / speed * 100)
 }
 
 // When the missionary is done traveling (a.k.a. sleeping) calls this in order
@@ -189,16 +260,24 @@ func (m *Mission) EndAttackMission(target *Planet) (excessShips int32, ownerHasC
 		m.Type = "Supply"
 		return m.EndSupplyMission(target)
 	} else {
-		if m.ShipCount < target.ShipCount {
-			target.SetShipCount(target.ShipCount - m.ShipCount)
+		defence := target.BuildingModifier(config.Defences)
+		if m.ShipCount < int32(float64(target.ShipCount)*defence) {
+			target.SetShipCount(target.ShipCount - int32(float64(m.ShipCount)/defence))
+			target.liftSiege()
 		} else {
-			if target.IsHome {
+			survivors := m.ShipCount - int32(float64(target.ShipCount)*defence)
+			if target.IsHome && !target.siegeIsOver() {
+				// The defenders are gone, but the home planet holds on
+				// until the siege is over and the survivors go back.
 				target.SetShipCount(0)
-				excessShips = m.ShipCount - target.ShipCount
+				target.besiege()
+				excessShips = survivors
 			} else {
-				target.SetShipCount(m.ShipCount - target.ShipCount)
+				target.SetShipCount(survivors)
 				target.Owner = m.Player
+				target.Construction = nil
 				target.Color = m.Color
+				target.liftSiege()
 				ownerHasChanged = true
 			}
 		}
@@ -219,14 +298,47 @@ func (m *Mission) EndSupplyMission(target *Planet) (int32, bool) {
 	return 0, false
 }
 
-// End Spy Mission: Create a spy report for that planet and find a way to notify the logged in
-// instances of the user who sent this mission.
-func (m *Mission) EndSpyMission(target *Planet) (int32, bool) {
+// End Spy Mission: Create a spy report for that planet, which costs a ship and
+// returns it, so the logged in instances of the user who sent this mission
+// could be notified. Spies sent to one of the player's own planets simply
+// land there and no report is created.
+func (m *Mission) EndSpyMission(target *Planet) *SpyReport {
 	if target.Owner == m.Player {
 		m.Target.Owner = target.Owner
-		return m.EndSupplyMission(target)
+		m.EndSupplyMission(target)
+		m.ShipCount = 0
+		m.ReportsLeft = 0
+		return nil
 	}
-	CreateSpyReport(target, m)
+	report := CreateSpyReport(target, m)
 	m.ShipCount -= 1
-	return 0, false
+	m.ReportsLeft -= 1
+	return report
+}
+
+// Turns the spies into a station above the target. Interval is the time
+// between two reports and duration is how long the station is kept, both
+// in seconds. Zero interval means a new report every time the previous
+// one expires and zero duration means until the spies run out of ships,
+// because every report costs a ship. The ships left when the station is
+// over return home.
+func (m *Mission) Station(interval, duration time.Duration) {
+	if interval <= 0 {
+		interval = m.ReportValidity()
+	}
+	if minimum := m.world.Settings().MinSpyReportInterval; interval < minimum {
+		interval = minimum
+	}
+	m.ReportInterval = interval
+	m.ReportsLeft = m.ShipCount
+
+	if duration > 0 {
+		reports := int32(duration / interval)
+		if reports < 1 {
+			reports = 1
+		}
+		if reports < m.ReportsLeft {
+			m.ReportsLeft = reports
+		}
+	}
 }
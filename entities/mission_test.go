@@ -103,7 +103,7 @@ func TestEndAttackMissionDenyTakeover(t *testing.T) {
 	)
 
 	endPlanet := new(Planet)
-	*endPlanet = Planet{"", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(2, 2), true, 6, 3, timeStamp, 2, 0, "chochko"}
+	*endPlanet = Planet{Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(2, 2), IsHome: true, Texture: 6, Size: 3, LastShipCountUpdate: timeStamp, ShipCount: 2, Owner: "chochko"}
 
 	mission.ShipCount = 5
 	excessShips, ownerHasChanged = mission.EndAttackMission(endPlanet)
@@ -120,8 +120,34 @@ func TestEndAttackMissionDenyTakeover(t *testing.T) {
 		t.Error("End Planet owner was expected to be chochko but is:", endPlanet.Owner)
 	}
 
-	if excessShips != 5 {
-		t.Error("There should be 5 excess ships, but the value is", excessShips)
+	if excessShips != 3 {
+		t.Error("There should be 3 excess ships, but the value is", excessShips)
+	}
+
+	if endPlanet.SiegedSince == 0 || endPlanet.SiegeRenewed != endPlanet.SiegedSince {
+		t.Error("The siege of the home planet has not started")
+	}
+}
+
+func TestEndAttackMissionSiegedHomePlanet(t *testing.T) {
+	endPlanet := new(Planet)
+	*endPlanet = Planet{Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(2, 2), IsHome: true, Texture: 6, Size: 3, LastShipCountUpdate: timeStamp, ShipCount: 2, Owner: "chochko"}
+	endPlanet.SiegedSince = time.Now().Add(-Settings().HomePlanetSiege*time.Second).UnixNano() / 1e6
+	endPlanet.SiegeRenewed = time.Now().Add(-Settings().HomePlanetSiege*time.Second/2).UnixNano() / 1e6
+
+	mission.ShipCount = 5
+	excessShips, ownerHasChanged := mission.EndAttackMission(endPlanet)
+
+	if !ownerHasChanged || endPlanet.Owner != mission.Player {
+		t.Error("The home planet has held on after the siege, the owner is", endPlanet.Owner)
+	}
+
+	if excessShips != 0 || endPlanet.GetShipCount() != 3 {
+		t.Errorf("The home planet has %d ships and %d excess ships", endPlanet.GetShipCount(), excessShips)
+	}
+
+	if endPlanet.SiegedSince != 0 || endPlanet.SiegeRenewed != 0 {
+		t.Error("The siege is not lifted after the home planet has fallen")
 	}
 }
 
@@ -131,8 +157,8 @@ func TestSegmentTravelTime(t *testing.T) {
 	expectedTime := time.Duration(7017)
 	time := calculateSegmentTravelTime(source, target, 10)
 
-	if Settings.MissionSpeed <= 0 {
-		t.Errorf("The mission speed in config is %d\n", Settings.MissionSpeed)
+	if Settings().MissionSpeed <= 0 {
+		t.Errorf("The mission speed in config is %d\n", Settings().MissionSpeed)
 	}
 
 	if time != expectedTime {
@@ -145,3 +171,100 @@ func TestSegmentTravelTime(t *testing.T) {
 		)
 	}
 }
+
+func TestTransferPoints(t *testing.T) {
+	areaSize := float64(Settings().AreaSize)
+	mission := &Mission{
+		Source: embeddedPlanet{Position: vec2d.New(areaSize/2, areaSize/2)},
+		Path:   []*vec2d.Vector{vec2d.New(areaSize*5/2, areaSize/2)},
+		Target: embeddedPlanet{Position: vec2d.New(areaSize*5/2, -areaSize/2)},
+		Type:   "Attack",
+	}
+	mission.SetShipCount(100)
+
+	expected := []struct {
+		distance  float64
+		axis      rune
+		direction int8
+	}{
+		{areaSize / 2, 'X', 1},
+		{areaSize, 'X', 1},
+		{areaSize, 'Y', -1},
+	}
+
+	transferPoints := mission.TransferPoints()
+	if len(transferPoints) != len(expected) {
+		t.Fatalf("The mission crosses %d borders instead of %d", len(transferPoints), len(expected))
+	}
+
+	var total time.Duration
+	for i, point := range transferPoints {
+		travelTime := time.Duration(expected[i].distance / mission.Speed * 100)
+		if point.CoordinateAxis != expected[i].axis || point.Direction != expected[i].direction {
+			t.Errorf("Transfer point %d is %c %d", i, point.CoordinateAxis, point.Direction)
+		}
+		if point.TravelTime < travelTime-1 || point.TravelTime > travelTime+1 {
+			t.Errorf("Transfer point %d is reached in %v instead of %v", i, point.TravelTime, travelTime)
+		}
+		total += point.TravelTime
+	}
+
+	if total > mission.TravelTime {
+		t.Errorf("The last border is crossed in %v, after the mission arrives in %v", total, mission.TravelTime)
+	}
+}
+
+func TestMissionSpeed(t *testing.T) {
+	spy := MissionSpeed("Spy", 1000, false)
+	smallAttack := MissionSpeed("Attack", 10, false)
+	bigAttack := MissionSpeed("Attack", 10000, false)
+
+	if !(spy > smallAttack && smallAttack > bigAttack) {
+		t.Errorf("Spies travel with %f, small attacks with %f and big ones with %f", spy, smallAttack, bigAttack)
+	}
+	if speed := MissionSpeed("Panda", 10, false); speed != float64(Settings().MissionSpeed) {
+		t.Errorf("Mission without a speed curve travels with %f", speed)
+	}
+	if boosted := MissionSpeed("Attack", 10, true); boosted != smallAttack*Settings().MissionBoostSpeed {
+		t.Errorf("Boosted mission travels with %f", boosted)
+	}
+}
+
+func TestMissionAreas(t *testing.T) {
+	areaSize := float64(Settings().AreaSize)
+	mission := &Mission{
+		Source: embeddedPlanet{Position: vec2d.New(areaSize/2, areaSize/2)},
+		Target: embeddedPlanet{Position: vec2d.New(areaSize*3/2, -areaSize*3/2)},
+		Type:   "Spy",
+	}
+	mission.SetAreaSet("area:1:1")
+	mission.SetShipCount(1)
+
+	areas := mission.Areas()
+	expected := []string{"area:1:1", "area:1:-1", "area:2:-1", "area:2:-2"}
+	if !reflect.DeepEqual(areas, expected) {
+		t.Errorf("The mission travels through %v instead of %v", areas, expected)
+	}
+}
+
+func TestSpyStation(t *testing.T) {
+	spies := &Mission{Type: "Spy", ShipCount: 10, SpyReportValidity: 30}
+
+	spies.Station(0, 0)
+	if spies.ReportInterval != 30 || spies.ReportsLeft != 10 {
+		t.Errorf("Default station reports %d times every %ds", spies.ReportsLeft, spies.ReportInterval)
+	}
+
+	spies.Station(1, 30)
+	if spies.ReportInterval != Settings().MinSpyReportInterval {
+		t.Errorf("Station reports every %ds, more often than allowed", spies.ReportInterval)
+	}
+	if expected := int32(30 / Settings().MinSpyReportInterval); spies.ReportsLeft != expected {
+		t.Errorf("Station reports %d times instead of %d", spies.ReportsLeft, expected)
+	}
+
+	spies.Station(20, 1000)
+	if spies.ReportsLeft != 10 {
+		t.Errorf("Station reports %d times with 10 ships", spies.ReportsLeft)
+	}
+}
@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Vladimiroff/vec2d"
+)
+
+// Checks if a mission could travel from source to target through the given
+// waypoints. The errors explain what's wrong, so they could be shown to
+// the player as they are.
+func ValidatePath(source, target *vec2d.Vector, path []*vec2d.Vector) error {
+	return defaultWorld.ValidatePath(source, target, path)
+}
+
+// Checks the path just like ValidatePath, but with the limits of the world.
+func (w *World) ValidatePath(source, target *vec2d.Vector, path []*vec2d.Vector) error {
+	settings := w.Settings()
+	if len(path) > settings.MaxWaypoints {
+		return fmt.Errorf("Too many waypoints: %d, the limit is %d.", len(path), settings.MaxWaypoints)
+	}
+
+	var length float64
+	previous := source
+	for i, waypoint := range path {
+		if waypoint == nil {
+			return fmt.Errorf("Waypoint %d is missing.", i+1)
+		}
+		if !isFinite(waypoint.X) || !isFinite(waypoint.Y) {
+			return fmt.Errorf("Waypoint %d has invalid coordinates.", i+1)
+		}
+		if math.Abs(waypoint.X) > settings.MaxCoordinate || math.Abs(waypoint.Y) > settings.MaxCoordinate {
+			return fmt.Errorf("Waypoint %d is outside of the universe.", i+1)
+		}
+		length += vec2d.GetDistance(previous, waypoint)
+		previous = waypoint
+	}
+	length += vec2d.GetDistance(previous, target)
+
+	if length > settings.MaxPathLength {
+		return fmt.Errorf("The path is too long: %.0f, the limit is %.0f.", length, settings.MaxPathLength)
+	}
+	return nil
+}
+
+func isFinite(coordinate float64) bool {
+	return !math.IsNaN(coordinate) && !math.IsInf(coordinate, 0)
+}
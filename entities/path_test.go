@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Vladimiroff/vec2d"
+)
+
+func TestValidatePath(t *testing.T) {
+	source := vec2d.New(0, 0)
+	target := vec2d.New(1000, 0)
+	tooMany := make([]*vec2d.Vector, Settings().MaxWaypoints+1)
+	for i := range tooMany {
+		tooMany[i] = vec2d.New(500, 0)
+	}
+
+	var tableTests = []struct {
+		path  []*vec2d.Vector
+		error string
+	}{
+		{nil, ""},
+		{[]*vec2d.Vector{vec2d.New(500, 500)}, ""},
+		{tooMany, "Too many waypoints"},
+		{[]*vec2d.Vector{vec2d.New(500, 500), nil}, "Waypoint 2 is missing"},
+		{[]*vec2d.Vector{vec2d.New(math.NaN(), 0)}, "Waypoint 1 has invalid coordinates"},
+		{[]*vec2d.Vector{vec2d.New(0, math.Inf(-1))}, "Waypoint 1 has invalid coordinates"},
+		{[]*vec2d.Vector{vec2d.New(0, Settings().MaxCoordinate*2)}, "Waypoint 1 is outside of the universe"},
+		{[]*vec2d.Vector{vec2d.New(0, Settings().MaxPathLength)}, "The path is too long"},
+	}
+
+	for _, test := range tableTests {
+		err := ValidatePath(source, target, test.path)
+		if test.error == "" && err != nil {
+			t.Errorf("Valid path %v is rejected with %s", test.path, err)
+		}
+		if test.error != "" && (err == nil || !strings.HasPrefix(err.Error(), test.error)) {
+			t.Errorf("Path %v is rejected with %v instead of %q", test.path, err, test.error)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"fmt"
+
+	"github.com/Vladimiroff/vec2d"
+)
+
+// Returns the username of the n-th pirate faction, starting from 1.
+func PirateName(n int) string {
+	return fmt.Sprintf("pirates%d", n)
+}
+
+// Creates a pirate faction. Pirates get a solar system of their own, just
+// like every new player, but they own all of its planets from the very
+// beginning. Nothing is saved, so it's up to the caller to do it.
+func CreatePirates(name string, race uint8) (*Player, *Sun, []*Planet) {
+	return defaultWorld.CreatePirates(name, race)
+}
+
+// Creates a pirate faction in the world.
+func (w *World) CreatePirates(name string, race uint8) (*Player, *Sun, []*Planet) {
+	setupData := &SetupData{Race: race}
+	sun := w.GenerateSun(name, []*Sun{}, setupData)
+	planets, homePlanet := w.GeneratePlanets(name, sun)
+	player := CreatePlayer(name, "", homePlanet, setupData)
+	player.NPC = true
+	player.ProtectedUntil = 0
+
+	for _, planet := range planets {
+		planet.Owner = player.Username
+		planet.Color = Races[race].Color
+		planet.ProtectedUntil = 0
+	}
+	return player, sun, planets
+}
+
+// Picks the target of the next pirate raid: the nearest planet of a player
+// within Settings().PirateRaidRange of the source. Planets of other NPC
+// factions, under newbie protection or on vacation are left alone.
+// Returns nil if there is no such planet.
+func RaidTarget(source *Planet, planets []*Planet, npcs map[string]bool) *Planet {
+	var target *Planet
+	distance := source.world.Settings().PirateRaidRange
+
+	for _, planet := range planets {
+		if !planet.HasOwner() || planet.Owner == source.Owner || npcs[planet.Owner] {
+			continue
+		}
+		if planet.IsProtected() || planet.OnVacation {
+			continue
+		}
+		if d := vec2d.GetDistance(source.Position, planet.Position); d <= distance {
+			target = planet
+			distance = d
+		}
+	}
+	return target
+}
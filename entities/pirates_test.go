@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+)
+
+func TestRaidTarget(t *testing.T) {
+	source := &Planet{Name: "PIR1230", Position: vec2d.New(0, 0), Owner: PirateName(1)}
+	planets := []*Planet{
+		source,
+		{Name: "PIR1231", Position: vec2d.New(10, 10), Owner: PirateName(1)},
+		{Name: "PIR2341", Position: vec2d.New(20, 20), Owner: PirateName(2)},
+		{Name: "GOP6721", Position: vec2d.New(30, 30)},
+		{Name: "NEW6721", Position: vec2d.New(100, 100), Owner: "newbie", ProtectedUntil: clock.NowMs() + 1000},
+		{Name: "AWA6721", Position: vec2d.New(200, 200), Owner: "traveller", OnVacation: true},
+		{Name: "GOP6722", Position: vec2d.New(500, 500), Owner: "gophie"},
+		{Name: "GOP6723", Position: vec2d.New(400, 400), Owner: "gophie"},
+		{Name: "PAN6721", Position: vec2d.New(1e9, 1e9), Owner: "panda"},
+	}
+	npcs := map[string]bool{PirateName(1): true, PirateName(2): true}
+
+	target := RaidTarget(source, planets, npcs)
+	if target == nil || target.Name != "GOP6723" {
+		t.Errorf("Pirates raid %#v instead of GOP6723", target)
+	}
+
+	if target := RaidTarget(source, planets[:4], npcs); target != nil {
+		t.Errorf("Pirates raid %s without any player around", target.Name)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/config"
 )
 
 type Planet struct {
@@ -19,12 +22,24 @@ type Planet struct {
 	ShipCount           int32
 	MaxShipCount        int32
 	Owner               string
+	Buildings           map[string]uint8 `json:",omitempty"`
+	Construction        *Construction    `json:",omitempty"`
+	Energy              float64
+	ProtectedUntil      int64 `json:",omitempty"` // Newbie protection of the owner, in ms
+	OnVacation          bool  `json:",omitempty"` // The owner is on vacation
+	SiegedSince         int64 `json:",omitempty"` // Start of the siege of a home planet, in ms
+	SiegeRenewed        int64 `json:",omitempty"` // Last won attack of the siege, in ms
+	world               *World
 }
 
+// The color of planets without an owner
+var NeutralPlanetColor = Color{0.78431373, 0.70588235, 0.54901961}
+
 // Used only when a planet is being marshalled
 type PlanetPacket struct {
 	Planet
-	IsSpied bool `json:",omitempty"`
+	IsSpied     bool `json:",omitempty"`
+	IsProtected bool `json:",omitempty"`
 }
 
 // Database key.
@@ -32,15 +47,59 @@ func (p *Planet) Key() string {
 	return fmt.Sprintf("planet.%s", p.Name)
 }
 
+func (p *Planet) setWorld(w *World) {
+	p.world = w
+}
+
 // Checks if the planet has an owner or not.
 func (p *Planet) HasOwner() bool {
 	return len(p.Owner) > 0
 }
 
+// Returns how long (in ms) a home planet has to be besieged before it
+// could fall.
+func (p *Planet) siegeDuration() int64 {
+	return int64(p.world.Settings().HomePlanetSiege * time.Second / time.Millisecond)
+}
+
+// Tells whether the home planet has been besieged for
+// Settings().HomePlanetSiege seconds, so it could fall.
+func (p *Planet) siegeIsOver() bool {
+	siege := p.siegeDuration()
+	return siege == 0 || p.SiegedSince != 0 && clock.NowMs()-p.SiegedSince >= siege
+}
+
+// Starts the siege of the home planet or renews the one going on.
+func (p *Planet) besiege() {
+	now := clock.NowMs()
+	if p.SiegedSince == 0 {
+		p.SiegedSince = now
+	}
+	p.SiegeRenewed = now
+}
+
+// Ends the siege of the home planet.
+func (p *Planet) liftSiege() {
+	p.SiegedSince = 0
+	p.SiegeRenewed = 0
+}
+
+// Lifts the siege once it hasn't been renewed for
+// Settings().HomePlanetSiege seconds or the planet has regrown the ships
+// of a new home planet.
+func (p *Planet) liftExpiredSiege() {
+	if p.SiegedSince == 0 {
+		return
+	}
+	if clock.NowMs()-p.SiegeRenewed > p.siegeDuration() || p.ShipCount >= p.world.Settings().InitialHomePlanetShipCount {
+		p.liftSiege()
+	}
+}
+
 // Returns the set by X or Y where this entity has to be put in
 func (p *Planet) AreaSet() string {
 	return fmt.Sprintf(
-		Settings.AreaTemplate,
+		Settings().AreaTemplate,
 		RoundCoordinateTo(p.Position.X),
 		RoundCoordinateTo(p.Position.Y),
 	)
@@ -48,12 +107,15 @@ func (p *Planet) AreaSet() string {
 
 // Checks what the player could see and strips it if not
 // Also updates the ship count right before marshaling
+// Nothing is stripped without a player, as spectators see everything.
 func (p *Planet) Sanitize(player *Player) *PlanetPacket {
 	p.UpdateShipCount()
-	packet := PlanetPacket{Planet: *p}
+	packet := PlanetPacket{Planet: *p, IsProtected: p.IsProtected()}
 
-	if p.Owner != player.Username {
+	if player != nil && p.Owner != player.Username {
 		packet.ShipCount = -1
+		packet.Energy = -1
+		packet.Construction = nil
 		for _, spyReport := range player.SpyReports {
 			if spyReport.Name == p.Name && spyReport.IsValid() {
 				packet.ShipCount = spyReport.ShipCount
@@ -75,87 +137,138 @@ func (p *Planet) GetShipCount() int32 {
 func (p *Planet) SetShipCount(count int32) {
 	p.UpdateShipCount()
 	p.ShipCount = count
-	p.LastShipCountUpdate = time.Now().Unix()
+	p.LastShipCountUpdate = clock.Now().Unix()
+}
+
+// Returns the production parameters of a planet with the given size.
+func PlanetProduction(size int8, isHome bool) *config.Production {
+	return defaultWorld.PlanetProduction(size, isHome)
 }
 
+// Returns the production parameters of a planet with the given size
+// in the world.
+func (w *World) PlanetProduction(size int8, isHome bool) *config.Production {
+	return w.Settings().Production.For(size, isHome)
+}
+
+// Returns how many seconds it takes a planet with the given size
+// to produce a single ship.
 func ShipCountTimeMod(size int8, isHome bool) int64 {
-	var timeModifier float64
-	if isHome {
-		timeModifier = Settings.ShipsPerMinuteHome
-	} else {
-		switch size {
-		case 1:
-			timeModifier = Settings.ShipsPerMinute1
-		case 2:
-			timeModifier = Settings.ShipsPerMinute2
-		case 3:
-			timeModifier = Settings.ShipsPerMinute3
-		case 4:
-			timeModifier = Settings.ShipsPerMinute4
-		case 5:
-			timeModifier = Settings.ShipsPerMinute5
-		case 6:
-			timeModifier = Settings.ShipsPerMinute6
-		case 7:
-			timeModifier = Settings.ShipsPerMinute7
-		case 8:
-			timeModifier = Settings.ShipsPerMinute8
-		case 9:
-			timeModifier = Settings.ShipsPerMinute9
-		case 10:
-			timeModifier = Settings.ShipsPerMinute10
-		}
-	}
-	return int64(timeModifier * 10)
+	return defaultWorld.ShipCountTimeMod(size, isHome)
 }
 
-// Updates the ship count based on last time this count has
+// Returns how many seconds it takes a planet with the given size
+// to produce a single ship in the world.
+func (w *World) ShipCountTimeMod(size int8, isHome bool) int64 {
+	return w.PlanetProduction(size, isHome).SecondsPerShip()
+}
+
+// Updates the ship count and the energy based on last time this count has
 // been updated and of course the planet size.
 // NOTE: If the planet is somebody's home we set a static increasion rate.
+// A construction finished in the meantime is applied right at the moment
+// it has finished, so the new building affects only the time after that.
 func (p *Planet) UpdateShipCount() {
-	if p.HasOwner() {
-		passedTime := time.Now().Unix() - p.LastShipCountUpdate
-		shipDiff := int32(passedTime / ShipCountTimeMod(p.Size, p.IsHome))
-
-		if p.ShipCount > p.MaxShipCount {
-			shipDiff *= int32(Settings.ShipsDeathModifier)
-			if (p.ShipCount - p.MaxShipCount) > shipDiff {
-				p.ShipCount -= shipDiff
-			} else {
-				p.ShipCount = p.MaxShipCount
-			}
+	now := clock.Now().Unix()
+	if p.Construction != nil && p.Construction.FinishesAt <= now {
+		p.updateShipCount(p.Construction.FinishesAt)
+		p.completeConstruction()
+	}
+	p.updateShipCount(now)
+	p.liftExpiredSiege()
+}
+
+func (p *Planet) updateShipCount(until int64) {
+	if !p.HasOwner() {
+		p.updateNeutralShipCount(until)
+		return
+	}
+
+	if p.OnVacation {
+		p.LastShipCountUpdate = until
+		return
+	}
+
+	production := p.world.PlanetProduction(p.Size, p.IsHome)
+	passedTime := until - p.LastShipCountUpdate
+	secondsPerShip := float64(production.SecondsPerShip()) / p.BuildingModifier(config.Shipyard)
+	shipDiff := int32(float64(passedTime) / secondsPerShip)
+	p.Energy += float64(passedTime) / 60 * production.EnergyPerMinute
+
+	if p.ShipCount > p.MaxShipCount {
+		shipDiff *= int32(production.DeathModifier)
+		if (p.ShipCount - p.MaxShipCount) > shipDiff {
+			p.ShipCount -= shipDiff
 		} else {
-			if (p.MaxShipCount - p.ShipCount) > shipDiff {
-				p.ShipCount += shipDiff
-			} else {
-				p.ShipCount = p.MaxShipCount
-			}
+			p.ShipCount = p.MaxShipCount
+		}
+	} else {
+		if (p.MaxShipCount - p.ShipCount) > shipDiff {
+			p.ShipCount += shipDiff
+		} else {
+			p.ShipCount = p.MaxShipCount
 		}
+	}
+
+	p.LastShipCountUpdate = until
+}
+
+// Neutral planets grow Settings().NeutralShipsPerMinute up to
+// Settings().NeutralMaxShipCount. Ships above the cap are never lost.
+// Only the time needed for the ships actually produced is counted, so
+// frequent updates don't stop the growth.
+func (p *Planet) updateNeutralShipCount(until int64) {
+	settings := p.world.Settings()
+	if settings.NeutralShipsPerMinute <= 0 || p.ShipCount >= settings.NeutralMaxShipCount {
+		p.LastShipCountUpdate = until
+		return
+	}
+
+	passedTime := until - p.LastShipCountUpdate
+	shipDiff := int32(float64(passedTime) / 60 * settings.NeutralShipsPerMinute)
+	if shipDiff <= 0 {
+		return
+	}
 
-		p.LastShipCountUpdate = time.Now().Unix()
+	if p.ShipCount+shipDiff >= settings.NeutralMaxShipCount {
+		p.ShipCount = settings.NeutralMaxShipCount
+		p.LastShipCountUpdate = until
+	} else {
+		p.ShipCount += shipDiff
+		p.LastShipCountUpdate += int64(float64(shipDiff) * 60 / settings.NeutralShipsPerMinute)
 	}
 }
 
 // Generates all planets in a solar system, based on the user's hash.
 func GeneratePlanets(nickname string, sun *Sun) ([]*Planet, *Planet) {
+	return defaultWorld.GeneratePlanets(nickname, sun)
+}
+
+// Generates all planets in a solar system of the world, based on the
+// user's hash.
+func (w *World) GeneratePlanets(nickname string, sun *Sun) ([]*Planet, *Planet) {
 	hash := GenerateHash(nickname)
 	hashElement := func(index int) float64 {
 		return float64(hash[index]) - 48 // The offset of simbol "1" in the ascii table
 	}
 
 	result := []*Planet{}
-	ringOffset := float64(Settings.PlanetsRingOffset)
-	planetRadius := float64(Settings.PlanetRadius)
-	homePlanetIdx := int(hashElement(Settings.PlanetCount*Settings.PlanetHashArgs + 1))
+	ringOffset := float64(Settings().PlanetsRingOffset)
+	planetRadius := float64(Settings().PlanetRadius)
+	// The digit is reduced to the planets in the system, which could be
+	// less than 10
+	homePlanetIdx := int(hashElement(Settings().PlanetCount*Settings().PlanetHashArgs+1)) % Settings().PlanetCount
+	sizes := w.Settings().Production.Sizes()
 
-	for ix := 0; ix < Settings.PlanetCount; ix++ {
+	for ix := 0; ix < Settings().PlanetCount; ix++ {
 		planet := Planet{
-			Color:        Color{0.78431373, 0.70588235, 0.54901961},
+			Color:        NeutralPlanetColor,
 			Position:     new(vec2d.Vector),
 			IsHome:       false,
-			ShipCount:    Settings.InitialPlanetShipCount,
+			ShipCount:    w.Settings().InitialPlanetShipCount,
 			MaxShipCount: 0,
 			Owner:        "",
+			world:        w,
 		}
 		// NOTE: 5 is the distance between planets
 		ringOffset += planetRadius + hashElement(4*ix)*5
@@ -164,13 +277,12 @@ func GeneratePlanets(nickname string, sun *Sun) ([]*Planet, *Planet) {
 		planet.Position.X = math.Floor(sun.Position.X + ringOffset*math.Cos(hashElement(4*ix+1)*40))
 		planet.Position.Y = math.Floor(sun.Position.Y + ringOffset*math.Sin(hashElement(4*ix+1)*40))
 		planet.Texture = int8(hashElement(4*ix + 2))
-		planet.Size = 1 + int8(hashElement(4*ix+3))
-		planet.LastShipCountUpdate = time.Now().Unix()
+		planet.Size = sizes[int(hashElement(4*ix+3))*len(sizes)/10] // spread the digit over all sizes
+		planet.LastShipCountUpdate = clock.Now().Unix()
 		planet.IsHome = (ix == homePlanetIdx)
-		planet.MaxShipCount = int32(Settings.PlanetMaxShipsMod * (60 / ShipCountTimeMod(planet.Size, planet.IsHome))) // the 60 is added so we have the propper spm and not the mod
-		// this is needed due to the last change to the ShipCountTimeMod change
+		planet.MaxShipCount = w.PlanetProduction(planet.Size, planet.IsHome).MaxShipCount()
 		if planet.IsHome {
-			planet.ShipCount = Settings.InitialHomePlanetShipCount
+			planet.ShipCount = w.Settings().InitialHomePlanetShipCount
 		}
 		result = append(result, &planet)
 	}
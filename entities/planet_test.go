@@ -8,20 +8,23 @@ import (
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/config"
 )
 
 func TestGeneratePlanets(t *testing.T) {
 	expectedPlanets := []Planet{
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-77, 57), false, 6, 3, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(1470, 300), false, 8, 5, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-690, -201), false, 3, 1, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-1052, 648), false, 2, 8, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(1428, -1364), false, 3, 1, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(2735, 300), false, 6, 8, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(2818, -799), false, 9, 6, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-323, 3080), false, 5, 4, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(1547, 3339), false, 1, 1, timeStamp, 10, 0, "gophie"},
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-2745, -1066), false, 4, 6, timeStamp, 10, 0, "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-77, 57), Texture: 6, Size: 3, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(1470, 300), Texture: 8, Size: 5, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-690, -201), Texture: 3, Size: 1, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-1052, 648), Texture: 2, Size: 8, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(1428, -1364), Texture: 3, Size: 1, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(2735, 300), Texture: 6, Size: 8, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(2818, -799), Texture: 9, Size: 6, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-323, 3080), Texture: 5, Size: 4, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(1547, 3339), Texture: 1, Size: 1, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-2745, -1066), Texture: 4, Size: 6, LastShipCountUpdate: timeStamp, ShipCount: 10, Owner: "gophie"},
 	}
 	sun.Position = vec2d.New(500, 300)
 	generatedPlanets, _ := GeneratePlanets("gophie", &sun)
@@ -45,26 +48,35 @@ func TestGeneratePlanets(t *testing.T) {
 	}
 }
 
-func TestUpdatePlanetShipCount(t *testing.T) {
-	var spmSize3 float64
-	var maxPlanetShipMod int64
-	defer func() {
-		Settings.ShipsPerMinute3 = spmSize3
-		Settings.PlanetMaxShipsMod = maxPlanetShipMod
-		return
-	}()
+func TestGeneratePlanetsOfSmallSystems(t *testing.T) {
+	defer setSettings(*Settings())
+	small := *Settings()
+	small.PlanetCount = 3
+	setSettings(small)
 
-	spmSize3 = Settings.ShipsPerMinute3
-	maxPlanetShipMod = Settings.PlanetMaxShipsMod
+	for _, name := range []string{"gophie", "chochko", "panda", "snake"} {
+		planets, home := GeneratePlanets(name, &sun)
+		if len(planets) != 3 || home == nil || !home.IsHome {
+			t.Errorf("%s got %d planets and %v for a home planet", name, len(planets), home)
+		}
+	}
+}
 
-	Settings.ShipsPerMinute3 = 1
-	Settings.PlanetMaxShipsMod = 10
+func TestUpdatePlanetShipCount(t *testing.T) {
+	defer setSettings(*Settings())
+
+	testSettings := *Settings()
+	testSettings.Production = config.ProductionTable{
+		config.HomeProduction: {ShipsPerMinute: 6, MaxShipsMod: 10, DeathModifier: 3},
+		"3":                   {ShipsPerMinute: 6, MaxShipsMod: 10, DeathModifier: 3},
+	}
+	setSettings(testSettings)
 
 	basePlanets := []Planet{
-		{"ABC1231", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-77, 57), false, 6, 3, time.Now().Unix() - 100, 170, 100, "gophie"},     //160
-		{"ABC1232", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(1470, 300), false, 8, 3, time.Now().Unix() - 6000, 10, 100, "gophie"},   //100
-		{"ABC1233", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(-690, -201), false, 3, 3, time.Now().Unix() - 6000, 110, 100, "gophie"}, //100
-		{"ABC1234", Color{0.59215686, 0.59215686, 0.59215686}, vec2d.New(1110, 200), false, 2, 3, time.Now().Unix() - 100, 50, 100, "gophie"},    //60
+		{Name: "ABC1231", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-77, 57), Texture: 6, Size: 3, LastShipCountUpdate: time.Now().Unix() - 100, ShipCount: 170, MaxShipCount: 100, Owner: "gophie"},     //160
+		{Name: "ABC1232", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(1470, 300), Texture: 8, Size: 3, LastShipCountUpdate: time.Now().Unix() - 6000, ShipCount: 10, MaxShipCount: 100, Owner: "gophie"},   //100
+		{Name: "ABC1233", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(-690, -201), Texture: 3, Size: 3, LastShipCountUpdate: time.Now().Unix() - 6000, ShipCount: 110, MaxShipCount: 100, Owner: "gophie"}, //100
+		{Name: "ABC1234", Color: Color{0.59215686, 0.59215686, 0.59215686}, Position: vec2d.New(1110, 200), Texture: 2, Size: 3, LastShipCountUpdate: time.Now().Unix() - 100, ShipCount: 50, MaxShipCount: 100, Owner: "gophie"},    //60
 	}
 
 	planetOneShipCount := basePlanets[0].GetShipCount()
@@ -88,6 +100,44 @@ func TestUpdatePlanetShipCount(t *testing.T) {
 	}
 }
 
+func TestUpdateNeutralPlanetShipCount(t *testing.T) {
+	defer setSettings(*Settings())
+
+	testSettings := *Settings()
+	testSettings.NeutralShipsPerMinute = 2
+	testSettings.NeutralMaxShipCount = 50
+	setSettings(testSettings)
+
+	lastUpdate := time.Now().Unix() - 45
+	neutral := Planet{Name: "ABC1231", Position: vec2d.New(1, 1), Size: 3, ShipCount: 10, LastShipCountUpdate: lastUpdate}
+
+	// 1.5 ships are produced, half a ship is left for the next update
+	if count := neutral.GetShipCount(); count != 11 {
+		t.Errorf("Neutral planet has %d ships instead of 11", count)
+	}
+	if neutral.LastShipCountUpdate != lastUpdate+30 {
+		t.Errorf("Neutral planet has been updated %ds after the last update", neutral.LastShipCountUpdate-lastUpdate)
+	}
+
+	neutral.LastShipCountUpdate -= 3600
+	if count := neutral.GetShipCount(); count != 50 {
+		t.Errorf("Neutral planet has %d ships above the cap", count)
+	}
+
+	crowded := Planet{Name: "ABC1232", Position: vec2d.New(1, 1), Size: 3, ShipCount: 80, LastShipCountUpdate: lastUpdate}
+	if count := crowded.GetShipCount(); count != 80 {
+		t.Errorf("Neutral planet above the cap has %d ships instead of 80", count)
+	}
+
+	testSettings.NeutralShipsPerMinute = 0
+	setSettings(testSettings)
+	neutral.ShipCount = 10
+	neutral.LastShipCountUpdate = lastUpdate
+	if count := neutral.GetShipCount(); count != 10 {
+		t.Errorf("Neutral planet grew to %d ships without growth", count)
+	}
+}
+
 func TestPlanetMarshalling(t *testing.T) {
 	var uPlanet Planet
 
@@ -125,3 +175,46 @@ func TestPlanetHasOwner(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSanitizeWithoutPlayer(t *testing.T) {
+	planet := newUpgradedPlanet(0)
+	planet.ShipCount = 42
+
+	if packet := planet.Sanitize(&Player{Username: "chochko"}); packet.ShipCount != -1 {
+		t.Errorf("Strangers see %d ships", packet.ShipCount)
+	}
+	if packet := planet.Sanitize(nil); packet.ShipCount != planet.ShipCount || packet.Energy != planet.Energy {
+		t.Errorf("Spectators see %d ships and %f energy", packet.ShipCount, packet.Energy)
+	}
+}
+
+func TestSiegeExpiry(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 11, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+	siege := Settings().HomePlanetSiege * time.Second
+
+	planet := &Planet{IsHome: true, Size: 3, Owner: "gophie", LastShipCountUpdate: fake.Now().Unix(), OnVacation: true}
+	planet.besiege()
+	start := planet.SiegedSince
+	fake.Advance(siege / 2)
+	planet.UpdateShipCount()
+	planet.besiege()
+	fake.Advance(siege / 2)
+	planet.UpdateShipCount()
+	if planet.SiegedSince != start || !planet.siegeIsOver() {
+		t.Fatalf("The renewed siege since %d is not over at %d", planet.SiegedSince, clock.NowMs())
+	}
+
+	fake.Advance(siege/2 + time.Second)
+	planet.UpdateShipCount()
+	if planet.SiegedSince != 0 || planet.siegeIsOver() {
+		t.Error("The siege is not lifted after it hasn't been renewed")
+	}
+
+	planet.besiege()
+	planet.SetShipCount(Settings().InitialHomePlanetShipCount)
+	planet.UpdateShipCount()
+	if planet.SiegedSince != 0 {
+		t.Errorf("The siege is not lifted after the planet has regrown %d ships", planet.ShipCount)
+	}
+}
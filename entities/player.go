@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/config"
 )
 
 type Player struct {
@@ -15,8 +18,14 @@ type Player struct {
 	HomePlanet     string
 	ScreenSize     []uint64
 	ScreenPosition *vec2d.Vector
+	NPC            bool         `json:",omitempty"` // Controlled by the server, nobody could log in as it
+	SunTextureId   uint16       // Kept for the next season
+	ProtectedUntil int64        `json:",omitempty"` // End of the newbie protection, in ms
+	VacationStart  int64        `json:",omitempty"` // Start of the current vacation, in ms
+	VacationEnd    int64        `json:",omitempty"` // End of the last vacation, in ms
 	SpyReports     []*SpyReport `json:"-" bson:"-"`
 	mutex          sync.Mutex
+	world          *World
 }
 
 // Database key.
@@ -24,9 +33,13 @@ func (p *Player) Key() string {
 	return fmt.Sprintf("player.%s", p.Username)
 }
 
+func (p *Player) setWorld(w *World) {
+	p.world = w
+}
+
 // Returns the sorted set by X or Y where this entity has to be put in
 func (p *Player) AreaSet() string {
-	homePlanet, _ := Get(p.HomePlanet)
+	homePlanet, _ := p.world.Get(p.HomePlanet)
 	return homePlanet.AreaSet()
 }
 
@@ -38,7 +51,7 @@ func (p *Player) Sun() string {
 // Starts missions to one of the players planet to some other. Each mission have type
 // and the user decides which part of the planet's fleet he would like to send.
 func (p *Player) StartMission(source, target *Planet, path []*vec2d.Vector, fleet int32, missionType string) *Mission {
-	currentTime := time.Now().UnixNano() / 1e6
+	currentTime := clock.NowMs()
 	baseShipCount := source.GetShipCount()
 	shipCount := int32(baseShipCount * fleet / 100)
 	source.SetShipCount(baseShipCount - shipCount)
@@ -61,41 +74,84 @@ func (p *Player) StartMission(source, target *Planet, path []*vec2d.Vector, flee
 		Player:    p.Username,
 		ShipCount: shipCount,
 		areaSet:   source.AreaSet(),
+		world:     source.world,
+	}
+	mission.updateSpeed()
+	if missionType == "Spy" {
+		validity := float64(source.world.Settings().SpyReportValidity) * source.BuildingModifier(config.Radar)
+		mission.SpyReportValidity = time.Duration(validity)
+		mission.Station(0, 0)
 	}
-	mission.TravelTime = calculateMissionTravelTime(source.Position, target.Position, path, Settings.MissionSpeed)
 	return &mission
 }
 
+// Returns the modifier of the radar on the player's home planet.
+// It widens the scope of view of the player.
+func (p *Player) RadarModifier() float64 {
+	entity, err := p.world.Get(p.HomePlanet)
+	if err != nil {
+		return 1
+	}
+	homePlanet := entity.(*Planet)
+	homePlanet.UpdateShipCount()
+	return homePlanet.BuildingModifier(config.Radar)
+}
+
 func (p *Player) UpdateSpyReports() {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	playersReports := Find(fmt.Sprintf("spy_report.%s_*", p.Username))
+	playersReports := p.world.Find(fmt.Sprintf("spy_report.%s_*", p.Username))
 	spyReports := make([]*SpyReport, 0, len(playersReports))
 	for _, reportEntity := range playersReports {
 		report := reportEntity.(*SpyReport)
 		if report.IsValid() {
 			spyReports = append(spyReports, report)
 		} else {
-			Delete(report.Key())
+			p.world.Delete(report.Key())
 		}
 	}
 	p.SpyReports = spyReports
 }
 
+// Returns the latest valid spy report of the player about the given planet
+// or nil if there isn't any.
+func (p *Player) SpyReport(planetName string) *SpyReport {
+	var latest *SpyReport
+	for _, report := range p.SpyReports {
+		if report.Name == planetName && report.IsValid() {
+			if latest == nil || report.CreatedAt > latest.CreatedAt {
+				latest = report
+			}
+		}
+	}
+	return latest
+}
+
 // Creates new player after the authentication and generates color based on the unique hash
 func CreatePlayer(username, TwitterID string, homePlanet *Planet, setupData *SetupData) *Player {
 	player := Player{
-		Username:       username,
-		TwitterID:      TwitterID,
-		HomePlanet:     homePlanet.Key(),
-		ScreenSize:     []uint64{0, 0},
-		ScreenPosition: homePlanet.Position,
+		Username:   username,
+		TwitterID:  TwitterID,
+		ScreenSize: []uint64{0, 0},
 	}
 
 	player.RaceID = setupData.Race
-
-	homePlanet.Owner = username
-	homePlanet.Color = Races[player.RaceID].Color
+	player.SunTextureId = setupData.SunTextureId
+	player.MoveHome(homePlanet)
 	return &player
 }
+
+// Gives the home planet to the player, centers their screen on it and
+// protects both like after a registration. The rest of the player stays
+// as it is.
+func (p *Player) MoveHome(homePlanet *Planet) {
+	p.HomePlanet = homePlanet.Key()
+	p.ScreenPosition = homePlanet.Position
+	p.world = homePlanet.world
+
+	homePlanet.Owner = p.Username
+	homePlanet.Color = Races[p.RaceID].Color
+	p.ProtectedUntil = homePlanet.world.newbieProtectionEnd()
+	homePlanet.ProtectedUntil = p.ProtectedUntil
+}
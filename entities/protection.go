@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"warcluster/clock"
+)
+
+// Newbie protection keeps new players safe from attacks for
+// Settings().NewbieProtection seconds or until they own
+// Settings().NewbieProtectionPlanets planets, whichever comes first. It
+// ends early as soon as they attack someone. The end of the protection is
+// kept on the planets of the player as well, so everyone sees it.
+
+// Tells whether the player is under newbie protection.
+func (p *Player) IsProtected() bool {
+	return p.ProtectedUntil > clock.NowMs()
+}
+
+// Tells whether the owner of the planet is under newbie protection.
+func (p *Planet) IsProtected() bool {
+	return p.ProtectedUntil > clock.NowMs()
+}
+
+// Returns when the protection of a player registered right now in the
+// world ends, 0 if there is no protection at all.
+func (w *World) newbieProtectionEnd() int64 {
+	duration := w.Settings().NewbieProtection * time.Second
+	if duration <= 0 {
+		return 0
+	}
+	return clock.Now().Add(duration).UnixNano() / 1e6
+}
+
+// Returns all planets of the player in the world.
+func (w *World) OwnedPlanets(username string) []*Planet {
+	var planets []*Planet
+	for _, entity := range w.Find("planet.*") {
+		if planet := entity.(*Planet); planet.Owner == username {
+			planets = append(planets, planet)
+		}
+	}
+	return planets
+}
+
+// Ends the protection of the player and saves them along with their
+// planets. Returns the planets which have changed.
+func (w *World) EndProtection(player *Player) []*Planet {
+	var changed []*Planet
+	player.ProtectedUntil = 0
+	w.Save(player)
+	for _, planet := range w.OwnedPlanets(player.Username) {
+		if planet.ProtectedUntil != 0 {
+			planet.ProtectedUntil = 0
+			w.Save(planet)
+			changed = append(changed, planet)
+		}
+	}
+	return changed
+}
+
+// Passes the protection of the new owner of the planet to it. The
+// protection of the owner ends once they own
+// Settings().NewbieProtectionPlanets planets. Returns the other planets
+// which have changed, the conquered one is not saved yet and is left to
+// the caller.
+func (w *World) ProtectConquest(owner *Player, planet *Planet) []*Planet {
+	planet.ProtectedUntil = 0
+	if !owner.IsProtected() {
+		return nil
+	}
+
+	planet.ProtectedUntil = owner.ProtectedUntil
+	limit := w.Settings().NewbieProtectionPlanets
+	if limit > 0 && len(w.OwnedPlanets(owner.Username))+1 >= limit {
+		planet.ProtectedUntil = 0
+		return w.EndProtection(owner)
+	}
+	return nil
+}
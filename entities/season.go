@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"warcluster/leaderboard"
+)
+
+// Season is the archive of a season played in a world: the leaderboard as
+// it was at the end and a JSON snapshot of everything in the world.
+// Start and End are in ms, like Mission.StartTime. The snapshot is only
+// for the admins, so it is never sent along with the standings.
+type Season struct {
+	Number   int
+	Start    int64
+	End      int64
+	Players  []*leaderboard.Player
+	Races    []*leaderboard.Race
+	Snapshot []byte `json:"-"`
+}
+
+// Database key.
+func (s *Season) Key() string {
+	return fmt.Sprintf("season.%d", s.Number)
+}
+
+// It has to be there in order to implement Entity
+func (s *Season) AreaSet() string {
+	return ""
+}
+
+// Entities which are gone at the end of a season. Players keep their
+// accounts and are placed again on their next login. Seasons and
+// tournaments are kept for the history.
+var seasonalEntities = []string{"planet", "sun", "ss", "mission", "spy_report", "history"}
+
+// Returns all seasons archived in the world, the latest first.
+func (w *World) Seasons() []*Season {
+	var seasons []*Season
+	for _, entity := range w.Find("season.*") {
+		seasons = append(seasons, entity.(*Season))
+	}
+	sort.Sort(latestSeasonFirst(seasons))
+	return seasons
+}
+
+// Deletes everything played in the world during the season along with
+// the players controlled by the server, which are spawned again.
+func (w *World) WipeSeason() error {
+	patterns := []string{strings.Replace(w.Settings().AreaTemplate, "%d", "*", -1)}
+	for _, entityType := range seasonalEntities {
+		patterns = append(patterns, entityType+".*")
+	}
+
+	for _, pattern := range patterns {
+		keys, err := w.GetList(pattern)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := w.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entity := range w.Find("player.*") {
+		if player := entity.(*Player); player.NPC {
+			if err := w.Delete(player.Key()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sorts seasons, latest first
+type latestSeasonFirst []*Season
+
+func (s latestSeasonFirst) Len() int           { return len(s) }
+func (s latestSeasonFirst) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s latestSeasonFirst) Less(i, j int) bool { return s[i].Number > s[j].Number }
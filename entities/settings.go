@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"warcluster/config"
+)
+
+// Holds the *config.Entities currently in use. It is swapped as a whole
+// on reload, so everyone sees either the old or the new settings.
+var settings struct {
+	sync.RWMutex
+	current *config.Entities
+}
+
+// Settings that can't change while the universe exists, because
+// everything already generated depends on them.
+var immutableSettings = []string{
+	"AreaSize",
+	"AreaTemplate",
+	"PlanetCount",
+	"PlanetHashArgs",
+	"PlanetRadius",
+	"PlanetsRingOffset",
+	"SolarSystemRadius",
+}
+
+// Returns the gameplay settings in use. The result is shared and must not
+// be modified, use ReloadSettings instead.
+func Settings() *config.Entities {
+	settings.RLock()
+	defer settings.RUnlock()
+	return settings.current
+}
+
+// Replaces the settings without any checks. Used on start.
+func setSettings(newSettings config.Entities) {
+	settings.Lock()
+	defer settings.Unlock()
+	settings.current = &newSettings
+}
+
+// Replaces the settings in use with the given ones. Changes in any of the
+// settings that can't be changed at runtime are rejected with an error and
+// the old settings stay in place.
+func ReloadSettings(newSettings config.Entities) error {
+	if err := checkImmutableSettings(Settings(), &newSettings); err != nil {
+		return err
+	}
+
+	setSettings(newSettings)
+	return nil
+}
+
+// Returns an error listing all settings which can't be changed at runtime,
+// but differ between the current settings and the new ones.
+func checkImmutableSettings(currentSettings, newSettings *config.Entities) error {
+	current := reflect.ValueOf(currentSettings).Elem()
+	updated := reflect.ValueOf(newSettings).Elem()
+
+	var changed []string
+	for _, name := range immutableSettings {
+		if current.FieldByName(name).Interface() != updated.FieldByName(name).Interface() {
+			changed = append(changed, name)
+		}
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf(
+			"%s can't be changed without a restart",
+			strings.Join(changed, ", "),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReloadSettings(t *testing.T) {
+	defer setSettings(*Settings())
+
+	newSettings := *Settings()
+	newSettings.MissionSpeed = 42
+	newSettings.SpyReportValidity = 7
+
+	if err := ReloadSettings(newSettings); err != nil {
+		t.Fatal(err)
+	}
+	if Settings().MissionSpeed != 42 || Settings().SpyReportValidity != 7 {
+		t.Errorf("Settings have not been reloaded: %#v", Settings())
+	}
+}
+
+func TestReloadImmutableSettings(t *testing.T) {
+	defer setSettings(*Settings())
+
+	oldSettings := *Settings()
+	newSettings := oldSettings
+	newSettings.MissionSpeed = 42
+	newSettings.AreaSize *= 2
+	newSettings.PlanetCount++
+
+	err := ReloadSettings(newSettings)
+	if err == nil {
+		t.Fatal("Immutable settings have been reloaded")
+	}
+	if !strings.Contains(err.Error(), "AreaSize") || !strings.Contains(err.Error(), "PlanetCount") {
+		t.Errorf("Error does not mention all changed settings: %s", err)
+	}
+	if !reflect.DeepEqual(*Settings(), oldSettings) {
+		t.Error("Settings have changed after a rejected reload")
+	}
+}
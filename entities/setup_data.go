@@ -14,7 +14,7 @@ type SetupData struct {
 
 // Database key.
 func (s *SetupData) Validate() error {
-	if s.SunTextureId < 0 || s.SunTextureId > Settings.SunTextures {
+	if s.SunTextureId < 0 || s.SunTextureId > Settings().SunTextures {
 		return errors.New("Sun testure index out of range.")
 	}
 	if s.Race >= uint8(len(Races)) {
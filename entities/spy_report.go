@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/config"
 )
 
 type SpyReport struct {
@@ -28,11 +31,11 @@ func (s *SpyReport) AreaSet() string {
 }
 
 func (s *SpyReport) IsValid() bool {
-	return s.ValidUntil > time.Now().Unix()
+	return s.ValidUntil > clock.Now().Unix()
 }
 
 func CreateSpyReport(target *Planet, mission *Mission) *SpyReport {
-	now := time.Now()
+	now := clock.Now()
 	report := &SpyReport{
 		Player:     mission.Player,
 		Name:       target.Name,
@@ -40,8 +43,22 @@ func CreateSpyReport(target *Planet, mission *Mission) *SpyReport {
 		Position:   target.Position,
 		ShipCount:  target.ShipCount,
 		CreatedAt:  now.Unix(),
-		ValidUntil: now.Add(Settings.SpyReportValidity * time.Second).Unix(),
+		ValidUntil: now.Add(mission.ReportValidity() * time.Second).Unix(),
 	}
-	Save(report)
+	mission.world.Save(report)
 	return report
 }
+
+// Estimates how strong the planet is going to be at the given time, based
+// only on what the report says about it. The planet is used just for what
+// everyone knows about it anyway - its size and buildings.
+func (s *SpyReport) EstimateDefence(planet *Planet, at time.Time) int32 {
+	estimate := *planet
+	estimate.Owner = s.Owner
+	estimate.ShipCount = s.ShipCount
+	estimate.LastShipCountUpdate = s.CreatedAt
+	estimate.Construction = nil
+	estimate.updateShipCount(at.Unix())
+
+	return int32(float64(estimate.ShipCount) * estimate.BuildingModifier(config.Defences))
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"warcluster/config"
+)
+
+func TestEstimateDefence(t *testing.T) {
+	planet := newUpgradedPlanet(500)
+	planet.Owner = "chochko"
+	planet.Buildings = map[string]uint8{config.Defences: 4}
+
+	createdAt := time.Now().Add(-27 * time.Second)
+	report := &SpyReport{
+		Player:    "gophie",
+		Name:      planet.Name,
+		Owner:     "chochko",
+		ShipCount: 100,
+		CreatedAt: createdAt.Unix(),
+	}
+
+	// One more ship is produced in 27 seconds and it is twice as strong
+	// with those defences.
+	arrival := createdAt.Add(27 * time.Second)
+	if strength := report.EstimateDefence(planet, arrival); strength != 202 {
+		t.Errorf("The defender strength is estimated to %d instead of 202", strength)
+	}
+	if planet.ShipCount != 500 {
+		t.Error("Estimating the defence changed the planet")
+	}
+}
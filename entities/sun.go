@@ -25,25 +25,32 @@ func (s *Sun) Key() string {
 // Returns the set by X or Y where this entity has to be put in
 func (s *Sun) AreaSet() string {
 	return fmt.Sprintf(
-		Settings.AreaTemplate,
+		Settings().AreaTemplate,
 		RoundCoordinateTo(s.Position.X),
 		RoundCoordinateTo(s.Position.Y),
 	)
 }
 
-// Generate sun's name out of user's initials and 3-digit random number
-func (s *Sun) generateName(nickname string) {
+// Generate sun's name out of user's initials and 3-digit random number.
+// Names for which taken returns true are skipped, so a player placed
+// again keeps clear of the solar system they have lost.
+func (s *Sun) generateName(nickname string, taken func(name string) bool) {
 	hash, _ := strconv.ParseInt(GenerateHash(nickname)[0:18], 10, 64)
 	random := rand.New(rand.NewSource(hash))
 	initials := extractUsernameInitials(nickname)
-	number := random.Int31n(899) + 100 // we need a 3-digit number
-	s.Name = fmt.Sprintf("%s%v", initials, number)
+	for attempt := 0; attempt < 899; attempt++ {
+		number := random.Int31n(899) + 100 // we need a 3-digit number
+		s.Name = fmt.Sprintf("%s%v", initials, number)
+		if !taken(s.Name) {
+			return
+		}
+	}
 }
 
 func (ss *Sun) calculateAdjacentSlots() []*SolarSlot {
-	verticalOffset := math.Floor((Settings.SolarSystemRadius * math.Sqrt(3) / 2) + 0.5)
-	angeledOffsetStepX := math.Floor((Settings.SolarSystemRadius / 2) + 0.5)
-	horizontalOffsetStepX := float64(Settings.SolarSystemRadius)
+	verticalOffset := math.Floor((Settings().SolarSystemRadius * math.Sqrt(3) / 2) + 0.5)
+	angeledOffsetStepX := math.Floor((Settings().SolarSystemRadius / 2) + 0.5)
+	horizontalOffsetStepX := float64(Settings().SolarSystemRadius)
 
 	slots := []*SolarSlot{
 		newSolarSlot(ss.Position.X-horizontalOffsetStepX, ss.Position.Y),
@@ -56,13 +63,13 @@ func (ss *Sun) calculateAdjacentSlots() []*SolarSlot {
 	return slots
 }
 
-func (ss *Sun) createAdjacentSlots() {
+func (ss *Sun) createAdjacentSlots(w *World) {
 	slots := ss.calculateAdjacentSlots()
 
 	for _, slot := range slots {
-		entity, _ := Get(slot.Key())
+		entity, _ := w.Get(slot.Key())
 		if entity == nil {
-			Save(slot)
+			w.Save(slot)
 		}
 	}
 }
@@ -71,7 +78,7 @@ func (ss *Sun) createAdjacentSlots() {
 func getStartSolarSlotPosition(friends []*Sun) *SolarSlot {
 	targetPosition := vec2d.New(0, 0)
 
-	verticalOffset := math.Floor(Settings.SolarSystemRadius * (math.Sqrt(3) / 2))
+	verticalOffset := math.Floor(Settings().SolarSystemRadius * (math.Sqrt(3) / 2))
 
 	//Find best position between all friends
 	for _, friend := range friends {
@@ -81,23 +88,23 @@ func getStartSolarSlotPosition(friends []*Sun) *SolarSlot {
 		targetPosition.DivToFloat64(float64(len(friends)))
 	}
 
-	//math.Floor(targetPosition.Y/Settings.SolarSystemRadius*(math.Sqrt(3)/2) + 0.5)
+	//math.Floor(targetPosition.Y/Settings().SolarSystemRadius*(math.Sqrt(3)/2) + 0.5)
 
 	//Approximate target to nearest node
 	verticalOffsetCoefficent := math.Floor((targetPosition.Y / verticalOffset) + 0.5)
 	if int64(verticalOffsetCoefficent)%2 != 0 {
-		targetPosition.X += Settings.SolarSystemRadius / 2
+		targetPosition.X += Settings().SolarSystemRadius / 2
 	}
-	targetPosition.X = Settings.SolarSystemRadius * math.Floor((targetPosition.X/Settings.SolarSystemRadius)+0.5)
+	targetPosition.X = Settings().SolarSystemRadius * math.Floor((targetPosition.X/Settings().SolarSystemRadius)+0.5)
 	targetPosition.Y = verticalOffset * verticalOffsetCoefficent
 	return newSolarSlot(targetPosition.X, targetPosition.Y)
 
 }
 
-func findHomeSolarSlot(rootSolarSlot *SolarSlot) *SolarSlot {
+func (w *World) findHomeSolarSlot(rootSolarSlot *SolarSlot) *SolarSlot {
 	var zLevel uint32 = 1
 
-	rootSolarSlotEntity, err := Get(rootSolarSlot.Key())
+	rootSolarSlotEntity, err := w.Get(rootSolarSlot.Key())
 	if err != nil && err != redis.ErrNil {
 		panic(err)
 	}
@@ -114,7 +121,7 @@ func findHomeSolarSlot(rootSolarSlot *SolarSlot) *SolarSlot {
 	for {
 		nodes := rootSolarSlot.fetchSolarSlotsLayer(zLevel)
 		for _, nodeKey := range nodes {
-			if node, err := Get(nodeKey); err == nil && node != nil {
+			if node, err := w.Get(nodeKey); err == nil && node != nil {
 				nodeEntity, _ := node.(*SolarSlot)
 				if nodeEntity.Data == "" {
 					return nodeEntity
@@ -131,19 +138,27 @@ func findHomeSolarSlot(rootSolarSlot *SolarSlot) *SolarSlot {
 // from the desired point and start to move it to THE POINT, but carefully
 // watching for collisions.
 func GenerateSun(username string, friends []*Sun, setupData *SetupData) *Sun {
+	return defaultWorld.GenerateSun(username, friends, setupData)
+}
+
+// Generates a sun just like GenerateSun, but in the world.
+func (w *World) GenerateSun(username string, friends []*Sun, setupData *SetupData) *Sun {
 	newSun := Sun{
 		Username:     username,
 		Position:     vec2d.New(0, 0),
 		SunTextureId: setupData.SunTextureId,
 	}
-	newSun.generateName(username)
+	newSun.generateName(username, func(name string) bool {
+		_, err := w.Get(fmt.Sprintf("sun.%s", name))
+		return err == nil
+	})
 
-	node := findHomeSolarSlot(getStartSolarSlotPosition(friends))
+	node := w.findHomeSolarSlot(getStartSolarSlotPosition(friends))
 	node.Data = newSun.Key()
-	Save(node)
+	w.Save(node)
 
 	newSun.Position.X = node.Position.X
 	newSun.Position.Y = node.Position.Y
-	newSun.createAdjacentSlots()
+	newSun.createAdjacentSlots(w)
 	return &newSun
 }
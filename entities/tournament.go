@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"warcluster/config"
+)
+
+// TournamentResult is the final standing of a tournament played in a
+// world. Start and End are in ms, like Mission.StartTime. Winner is the name of a player or of a race, depending on the
+// win condition, and is empty if the first two are even.
+type TournamentResult struct {
+	Start        int64
+	End          int64
+	WinCondition string
+	Winner       string
+	Players      []*Standing
+	Races        []*Standing
+}
+
+// Standing is the place of a player or a race at the end of a
+// tournament. HomePlanet tells whether a player still owns their home
+// planet.
+type Standing struct {
+	Name       string
+	Race       string `json:",omitempty"`
+	Planets    int
+	HomePlanet bool `json:",omitempty"`
+}
+
+// Database key.
+func (t *TournamentResult) Key() string {
+	return fmt.Sprintf("tournament.%d", t.Start)
+}
+
+// It has to be there in order to implement Entity
+func (t *TournamentResult) AreaSet() string {
+	return ""
+}
+
+// Ranks the players and the races of the world by the win condition and
+// picks the winner. Players controlled by the server do not take part.
+func (w *World) TournamentResult(start, end time.Time, winCondition string) *TournamentResult {
+	result := &TournamentResult{
+		Start:        start.UnixNano() / 1e6,
+		End:          end.UnixNano() / 1e6,
+		WinCondition: winCondition,
+	}
+
+	planets := make(map[string]int)
+	homes := make(map[string]string)
+	for _, entity := range w.Find("planet.*") {
+		planet := entity.(*Planet)
+		if planet.Owner != "" {
+			planets[planet.Owner]++
+		}
+		if planet.IsHome {
+			homes[planet.Key()] = planet.Owner
+		}
+	}
+
+	races := make(map[string]*Standing)
+	for _, entity := range w.Find("player.*") {
+		player := entity.(*Player)
+		if player.NPC {
+			continue
+		}
+		standing := &Standing{
+			Name:       player.Username,
+			Planets:    planets[player.Username],
+			HomePlanet: homes[player.HomePlanet] == player.Username,
+		}
+		if int(player.RaceID) < len(Races) {
+			standing.Race = Races[player.RaceID].Name
+		}
+		result.Players = append(result.Players, standing)
+
+		race, ok := races[standing.Race]
+		if !ok {
+			race = &Standing{Name: standing.Race}
+			races[standing.Race] = race
+			result.Races = append(result.Races, race)
+		}
+		race.Planets += standing.Planets
+	}
+
+	lastHome := winCondition == config.WinLastHomeStanding
+	sort.Sort(byStanding{result.Players, lastHome})
+	sort.Sort(byStanding{result.Races, false})
+
+	ranked := result.Players
+	if winCondition == config.WinMostRacePlanets {
+		ranked = result.Races
+	}
+	switch {
+	case len(ranked) == 0:
+	case lastHome && !ranked[0].HomePlanet:
+	case len(ranked) == 1 || ranked[0].ahead(ranked[1], lastHome):
+		result.Winner = ranked[0].Name
+	}
+	return result
+}
+
+// Returns the results of all tournaments played in the world, the latest
+// first.
+func (w *World) TournamentResults() []*TournamentResult {
+	var results []*TournamentResult
+	for _, entity := range w.Find("tournament.*") {
+		results = append(results, entity.(*TournamentResult))
+	}
+	sort.Sort(latestTournamentFirst(results))
+	return results
+}
+
+// Tells whether s is placed before other. The ones which still own their
+// home planet come first if homeFirst is set, then the ones with more
+// planets.
+func (s *Standing) ahead(other *Standing, homeFirst bool) bool {
+	if homeFirst && s.HomePlanet != other.HomePlanet {
+		return s.HomePlanet
+	}
+	return s.Planets > other.Planets
+}
+
+// Sorts standings by their place, even ones by name
+type byStanding struct {
+	standings []*Standing
+	homeFirst bool
+}
+
+func (s byStanding) Len() int      { return len(s.standings) }
+func (s byStanding) Swap(i, j int) { s.standings[i], s.standings[j] = s.standings[j], s.standings[i] }
+func (s byStanding) Less(i, j int) bool {
+	a, b := s.standings[i], s.standings[j]
+	if a.ahead(b, s.homeFirst) || b.ahead(a, s.homeFirst) {
+		return a.ahead(b, s.homeFirst)
+	}
+	return a.Name < b.Name
+}
+
+// Sorts tournament results, latest first
+type latestTournamentFirst []*TournamentResult
+
+func (t latestTournamentFirst) Len() int           { return len(t) }
+func (t latestTournamentFirst) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t latestTournamentFirst) Less(i, j int) bool { return t[i].Start > t[j].Start }
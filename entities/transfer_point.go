@@ -25,8 +25,10 @@ func (a AreaTransferPoints) Less(i, j int) bool {
 	return a[i].TravelTime < a[j].TravelTime
 }
 
-func (a AreaTransferPoints) Append(elems ...*AreaTransferPoint) {
-	a = append(a, elems...)
+// Appends the points in place. It used to append to a copy, so no
+// mission has ever changed its area before.
+func (a *AreaTransferPoints) Append(elems ...*AreaTransferPoint) {
+	*a = append(*a, elems...)
 }
 
 func (a AreaTransferPoints) Size() int {
@@ -7,10 +7,11 @@ import (
 	"math"
 	"math/rand"
 	"strconv"
-	"time"
 	"unicode"
 
 	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
 )
 
 type CartesianEquation struct {
@@ -68,8 +69,8 @@ func simplifyHash(hash []byte) string {
 // Returns some random start position for a sun, before starting
 // to move it over the galaxy
 func getRandomStartPosition(scope int) *vec2d.Vector {
-	xSeed := time.Now().UTC().UnixNano()
-	ySeed := time.Now().UTC().UnixNano()
+	xSeed := clock.Now().UTC().UnixNano()
+	ySeed := clock.Now().UTC().UnixNano()
 	xGenerator := rand.New(rand.NewSource(xSeed))
 	yGenerator := rand.New(rand.NewSource(ySeed))
 	return vec2d.New(
@@ -105,7 +106,7 @@ func extractUsernameInitials(nickname string) string {
 }
 
 func RoundCoordinateTo(coordinate float64) int64 {
-	value := coordinate / float64(Settings.AreaSize)
+	value := coordinate / float64(Settings().AreaSize)
 	if value > 0 {
 		value = math.Floor(value) + 1
 	} else if value == 0 {
@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"warcluster/clock"
+)
+
+// Players on vacation could not be attacked, their planets do not produce
+// anything and they could not send missions. A vacation lasts at least
+// Settings().VacationMinDuration seconds and the next one could start
+// Settings().VacationCooldown seconds after it has ended. Planets are
+// marked as well, so their production stops and everyone sees it.
+
+// Tells whether the player is on vacation.
+func (p *Player) OnVacation() bool {
+	return p.VacationStart > 0
+}
+
+// Returns the earliest time (in ms) the current vacation of the player in
+// the world could end.
+func (w *World) VacationMinEnd(player *Player) int64 {
+	return player.VacationStart + int64(w.Settings().VacationMinDuration*time.Second/time.Millisecond)
+}
+
+// Returns the earliest time (in ms) the player could go on vacation again
+// in the world.
+func (w *World) NextVacation(player *Player) int64 {
+	if player.VacationEnd == 0 {
+		return 0
+	}
+	return player.VacationEnd + int64(w.Settings().VacationCooldown*time.Second/time.Millisecond)
+}
+
+// Sends the player on vacation and saves them along with their planets,
+// which stop producing right now. Returns the planets.
+func (w *World) StartVacation(player *Player) ([]*Planet, error) {
+	now := clock.NowMs()
+	if player.OnVacation() {
+		return nil, errors.New("The player is already on vacation.")
+	}
+	if next := w.NextVacation(player); now < next {
+		return nil, fmt.Errorf("The next vacation could start in %s.", vacationWait(next-now))
+	}
+
+	player.VacationStart = now
+	planets := w.OwnedPlanets(player.Username)
+	for _, planet := range planets {
+		planet.UpdateShipCount()
+		planet.OnVacation = true
+		w.Save(planet)
+	}
+	w.Save(player)
+	return planets, nil
+}
+
+// Brings the player back from vacation and saves them along with their
+// planets, which produce from now on. Returns the planets.
+func (w *World) EndVacation(player *Player) ([]*Planet, error) {
+	now := clock.NowMs()
+	if !player.OnVacation() {
+		return nil, errors.New("The player is not on vacation.")
+	}
+	if end := w.VacationMinEnd(player); now < end {
+		return nil, fmt.Errorf("The vacation could end in %s.", vacationWait(end-now))
+	}
+
+	planets := w.OwnedPlanets(player.Username)
+	for _, planet := range planets {
+		planet.UpdateShipCount()
+		planet.OnVacation = false
+		w.Save(planet)
+	}
+	player.VacationStart = 0
+	player.VacationEnd = now
+	w.Save(player)
+	return planets, nil
+}
+
+// Formats the given ms to wait, rounded up to a minute.
+func vacationWait(ms int64) time.Duration {
+	wait := time.Duration(ms)*time.Millisecond + time.Minute - 1
+	return wait - wait%time.Minute
+}
@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"sync"
+
+	"github.com/garyburd/redigo/redis"
+
+	"warcluster/config"
+	"warcluster/entities/db"
+)
+
+// World is a universe of its own: the database its entities are stored in
+// and the gameplay settings they are played by. Many worlds could live in
+// a single process. They all share the settings which can't change at
+// runtime (see immutableSettings), so the galaxy looks the same in each.
+//
+// The nil *World is the default one. It is stored in db.Pool and played by
+// Settings(), which is what all functions of the package use.
+type World struct {
+	pool     *redis.Pool
+	mutex    sync.RWMutex
+	settings *config.Entities
+}
+
+// The world used by the functions of the package.
+var defaultWorld *World
+
+// Implemented by the entities which have to know the world they are in.
+type worldEntity interface {
+	setWorld(*World)
+}
+
+// Creates a world stored in the given database and played by the given
+// settings. The settings which can't change at runtime have to be the
+// same as the ones of the default world.
+func NewWorld(pool *redis.Pool, settings config.Entities) (*World, error) {
+	if err := checkImmutableSettings(Settings(), &settings); err != nil {
+		return nil, err
+	}
+
+	return &World{pool: pool, settings: &settings}, nil
+}
+
+// Returns the gameplay settings of the world. The result is shared and
+// must not be modified, use ReloadSettings instead.
+func (w *World) Settings() *config.Entities {
+	if w == nil {
+		return Settings()
+	}
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.settings
+}
+
+// Replaces the settings of the world just like ReloadSettings does.
+func (w *World) ReloadSettings(newSettings config.Entities) error {
+	if w == nil {
+		return ReloadSettings(newSettings)
+	}
+	if err := w.CheckSettings(newSettings); err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.settings = &newSettings
+	return nil
+}
+
+// Returns an error if the world could not switch to the given settings
+// at runtime, without switching to them.
+func (w *World) CheckSettings(newSettings config.Entities) error {
+	return checkImmutableSettings(w.Settings(), &newSettings)
+}
+
+// Returns a connection to the database of the world. It has to be closed
+// once done with it.
+func (w *World) Conn() redis.Conn {
+	if w == nil {
+		return db.Pool.Get()
+	}
+	return w.pool.Get()
+}
@@ -13,10 +13,22 @@ type Color struct {
 }
 
 type Player struct {
-	Username   string
-	RaceId     uint8
-	HomePlanet string
-	Planets    uint32
+	Username        string
+	RaceId          uint8
+	HomePlanet      string
+	Planets         uint32
+	EnergyPerMinute float64
+	Eliminations    uint32 // Players whose home planet they have taken
+	Eliminated      uint32 // Times they have lost their home planet
+}
+
+// PlanetTransfer is sent over Leaderboard.Channel every time a planet
+// changes its owner.
+type PlanetTransfer struct {
+	From            string
+	To              string
+	EnergyPerMinute float64
+	Eliminated      bool // The planet was the home planet of From
 }
 
 type Race struct {
@@ -32,7 +44,7 @@ type Leaderboard struct {
 	places  map[string]int
 	board   []*Player
 	races   Races
-	Channel chan [2]string
+	Channel chan PlanetTransfer
 }
 
 func New() *Leaderboard {
@@ -40,13 +52,17 @@ func New() *Leaderboard {
 	l.places = make(map[string]int)
 	l.board = make([]*Player, 0)
 	l.races = make([]*Race, 0)
-	l.Channel = make(chan [2]string)
+	l.Channel = make(chan PlanetTransfer)
 
 	go func(l *Leaderboard) {
-		var transfer [2]string
+		var transfer PlanetTransfer
 		for {
 			transfer = <-l.Channel
-			l.Transfer(transfer[0], transfer[1])
+			l.Transfer(transfer.From, transfer.To)
+			l.TransferEnergy(transfer.From, transfer.To, transfer.EnergyPerMinute)
+			if transfer.Eliminated {
+				l.Eliminate(transfer.From, transfer.To)
+			}
 		}
 	}(l)
 
@@ -133,6 +149,41 @@ func (l *Leaderboard) Transfer(from_username, to_username string) {
 	l.races.Sort()
 }
 
+// Moves the energy production of a planet from one player to another.
+// It doesn't affect the places, players are ordered by their planets.
+func (l *Leaderboard) TransferEnergy(from_username, to_username string, energyPerMinute float64) {
+	if from, ok := l.places[from_username]; ok {
+		l.board[from].EnergyPerMinute -= energyPerMinute
+	}
+	if to, ok := l.places[to_username]; ok {
+		l.board[to].EnergyPerMinute += energyPerMinute
+	}
+}
+
+// Records that one player has taken the home planet of another.
+func (l *Leaderboard) Eliminate(eliminated, by string) {
+	if place, ok := l.places[eliminated]; ok {
+		l.board[place].Eliminated++
+	}
+	if place, ok := l.places[by]; ok {
+		l.board[place].Eliminations++
+	}
+}
+
+// Gives an eliminated player their new home planet. Returns false if the
+// player is not on the board, so they have to be added instead.
+func (l *Leaderboard) Respawn(username, homePlanet string, energyPerMinute float64) bool {
+	place, ok := l.places[username]
+	if !ok {
+		return false
+	}
+
+	l.board[place].HomePlanet = homePlanet
+	l.Transfer("", username)
+	l.TransferEnergy("", username, energyPerMinute)
+	return true
+}
+
 func (l *Leaderboard) Page(page int64) ([]*Player, error) {
 	if page <= 0 {
 		return []*Player{}, errors.New("No such page")
@@ -158,6 +209,11 @@ func (l *Leaderboard) Place(username string) int {
 	return place
 }
 
+// Returns all players, ordered by their place.
+func (l *Leaderboard) Players() []*Player {
+	return l.board
+}
+
 func (l *Leaderboard) Races() []*Race {
 	return l.races
 
@@ -69,6 +69,40 @@ func TestAddRace(t *testing.T) {
 	}
 }
 
+func TestTransferEnergy(t *testing.T) {
+	l := initLeaderboard()
+	l.board[0].EnergyPerMinute = 10
+	l.board[1].EnergyPerMinute = 8
+
+	l.TransferEnergy("1", "0", 2.5)
+	if l.board[0].EnergyPerMinute != 12.5 || l.board[1].EnergyPerMinute != 5.5 {
+		t.Errorf("Energy after the transfer is %f and %f", l.board[0].EnergyPerMinute, l.board[1].EnergyPerMinute)
+	}
+
+	l.TransferEnergy("", "1", 2.5)
+	if l.board[1].EnergyPerMinute != 8 {
+		t.Errorf("Neutral planet gave %f energy", l.board[1].EnergyPerMinute-5.5)
+	}
+}
+
+func TestEliminate(t *testing.T) {
+	l := initLeaderboard()
+	l.Eliminate("3", "0")
+	if l.board[3].Eliminated != 1 || l.board[0].Eliminations != 1 {
+		t.Errorf("3 is eliminated %d times and 0 has %d eliminations", l.board[3].Eliminated, l.board[0].Eliminations)
+	}
+
+	if l.Respawn("unknown", "planet", 1) {
+		t.Error("A player who is not on the board has respawned")
+	}
+	if !l.Respawn("3", "NEW1234", 2.5) {
+		t.Fatal("3 has not respawned")
+	}
+	if player := l.board[l.Place("3")]; player.HomePlanet != "NEW1234" || player.Planets != 7 || player.EnergyPerMinute != 2.5 {
+		t.Errorf("3 has respawned at %s with %d planets and %f energy", player.HomePlanet, player.Planets, player.EnergyPerMinute)
+	}
+}
+
 func TestSimpleTransfer(t *testing.T) {
 	l := initLeaderboard()
 	l.Transfer("1", "0")
@@ -2,37 +2,202 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"syscall"
+	"time"
 
 	"warcluster/config"
+	"warcluster/entities"
 	"warcluster/entities/db"
 	"warcluster/leaderboard"
 	"warcluster/server"
 )
 
-var cfg config.Config
+var (
+	cfg       config.Config
+	overrides config.Overrides
+
+	configPath = flag.String("config", "", "path to the config file (default: config/config.gcfg)")
+	database   = flag.Uint("db", 8, "index of the redis database")
+	listen     = flag.String("listen", "", "address to listen on, e.g. 0.0.0.0:7000 (default: taken from the config)")
+	universeID = flag.String("universe", "", "run the command on the universe with this id from the config (default: the default universe)")
+)
 
 func init() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
+	flag.Var(&overrides, "set", "override a config value, e.g. -set entities.missionSpeed=8 (repeatable)")
 }
 
 func main() {
-	cfg.Load()
-	db.InitPool(cfg.Database.Host, cfg.Database.Port, 8)
+	flag.Usage = usage
+	flag.Parse()
+
+	var err error
+	if cfg, err = config.Read(*configPath, os.Environ(), overrides); err != nil {
+		log.Fatal(err)
+	}
+	if *database > 15 {
+		log.Fatalf("Invalid database index %d", *database)
+	}
+
+	db.InitPool(cfg.Database.Host, cfg.Database.Port, uint8(*database))
+	if *universeID != "" {
+		if err := selectUniverse(*universeID); err != nil {
+			log.Fatal(err)
+		}
+	}
+	entities.ExportConfig(cfg)
 	server.ExportConfig(cfg)
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := command(args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [command]\n\nCommands:\n", os.Args[0])
+	fmt.Fprint(os.Stderr, commandsUsage)
+	fmt.Fprint(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// Puts the universe with the given id in place of the default one, so
+// the maintenance commands work on its database with its settings.
+func selectUniverse(id string) error {
+	params, ok := cfg.Universe[id]
+	if !ok {
+		return fmt.Errorf("Unknown universe %q", id)
+	}
+	settings, err := cfg.UniverseEntities(id)
+	if err != nil {
+		return err
+	}
+
+	db.Pool = db.NewPool(cfg.Database.Host, cfg.Database.Port, params.Database)
+	cfg.Entities = settings
+	return nil
+}
+
+// Handles `warcluster serve`. Starts the game server, which is also what
+// happens when no command is given at all.
+func serveCommand(args []string) error {
+	if *universeID != "" {
+		return errors.New("The universes are served along with the default one, -universe is only for the other commands")
+	}
+	host, port, err := listenAddress()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Server.ReplayLog != "" {
+		if err := server.StartRecording(cfg.Server.ReplayLog); err != nil {
+			return err
+		}
+	}
+	server.SetConfigLoader(func() (config.Config, error) {
+		return config.Read(*configPath, os.Environ(), overrides)
+	})
 	server.InitLeaderboard(leaderboard.New())
 	server.SpawnDbMissions()
+	server.StartPirates()
+	if err := startUniverses(); err != nil {
+		return err
+	}
 
-	s := server.NewServer(
-		cfg.Server.Host,
-		cfg.Server.Port,
-	)
+	s := server.NewServer(host, port)
 	go final(s)
+	go reload()
 
 	s.Start()
+	return nil
+}
+
+// Starts all universes in the config next to the default one.
+func startUniverses() error {
+	for id, params := range cfg.Universe {
+		if uint(params.Database) == *database {
+			return fmt.Errorf("Universe %s uses the database of the default universe", id)
+		}
+		settings, err := cfg.UniverseEntities(id)
+		if err != nil {
+			return err
+		}
+
+		pool := db.NewPool(cfg.Database.Host, cfg.Database.Port, params.Database)
+		universe, err := server.NewUniverse(id, pool, settings)
+		if err != nil {
+			return err
+		}
+		if params.ReplayLog != "" {
+			if err := universe.StartRecording(params.ReplayLog); err != nil {
+				return err
+			}
+		}
+		if !params.TournamentStart.IsZero() {
+			universe.HostTournament(server.NewTournament(
+				params.TournamentStart,
+				params.TournamentDuration*time.Second,
+				params.TournamentWin,
+			))
+		}
+		if err := universe.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the address given with -listen or the one in the config.
+func listenAddress() (string, uint16, error) {
+	if *listen == "" {
+		return cfg.Server.Host, cfg.Server.Port, nil
+	}
+	return parseAddress(*listen)
+}
+
+// Splits host:port into its parts.
+func parseAddress(address string) (string, uint16, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid port %q", portString)
+	}
+	return host, uint16(port), nil
+}
+
+// Reloads the gameplay settings on every SIGHUP.
+func reload() {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	for _ = range hupChan {
+		log.Println("SIGHUP received, reloading the config...")
+		if err := server.Reload(); err != nil {
+			log.Println("Error reloading the config:", err)
+		}
+	}
 }
 
 func final(s *server.Server) {
@@ -43,5 +208,6 @@ func final(s *server.Server) {
 	<-exitChan
 
 	s.Stop()
+	server.StopRecording()
 	os.Exit(0)
 }
@@ -0,0 +1,165 @@
+// Package replay records what happens in a game into an append-only log
+// and plays it back later, so finished games could be watched and analyzed.
+//
+// The log holds one JSON encoded Event per line.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"warcluster/clock"
+)
+
+// Types of the recorded events.
+const (
+	Command         = "command"          // a command accepted from a player
+	MissionLaunched = "mission_launched" // Data is the mission
+	AreaTransfer    = "area_transfer"    // Data is an AreaTransferData
+	Battle          = "battle"           // Data is a BattleData
+	OwnerChange     = "owner_change"     // Data is an OwnerChangeData
+	SpyReport       = "spy_report"       // Data is the spy report
+)
+
+// Event is a single line of the log. Time is in ms, just like
+// Mission.StartTime.
+type Event struct {
+	Time   int64
+	Type   string
+	Player string `json:",omitempty"`
+	Data   json.RawMessage
+}
+
+// AreaTransferData describes a mission flying into another area.
+type AreaTransferData struct {
+	Mission string
+	Area    string
+}
+
+// BattleData describes the end of an attack.
+type BattleData struct {
+	Mission   string
+	Planet    string
+	Attacker  string
+	Defender  string
+	Attackers int32 // ships of the attacker
+	Defenders int32 // ships on the planet before the attack
+	ShipsLeft int32 // ships on the planet after the attack
+}
+
+// OwnerChangeData describes a planet changing its owner.
+type OwnerChangeData struct {
+	Planet string
+	From   string
+	To     string
+}
+
+// Recorder appends events to a log. It is safe for concurrent use.
+type Recorder struct {
+	mutex   sync.Mutex
+	closer  io.Closer
+	encoder *json.Encoder
+}
+
+// Creates a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{encoder: json.NewEncoder(w)}
+	if closer, ok := w.(io.Closer); ok {
+		r.closer = closer
+	}
+	return r
+}
+
+// Opens the log at the given path for recording. Events are appended to
+// the ones already there.
+func Open(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(file), nil
+}
+
+// Appends an event happening right now.
+func (r *Recorder) Record(eventType, player string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.encoder.Encode(&Event{
+		Time:   clock.NowMs(),
+		Type:   eventType,
+		Player: player,
+		Data:   raw,
+	})
+}
+
+// Closes the log, if there is anything to close.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Reads all events of a log. The last line is left out if it isn't a
+// whole event, because that's what a crash leaves in the log.
+func Read(r io.Reader) ([]*Event, error) {
+	var events []*Event
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		last := err == io.EOF
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			event := new(Event)
+			if err := json.Unmarshal(line, event); err != nil {
+				if last {
+					break
+				}
+				return nil, fmt.Errorf("Event %d: %s", len(events)+1, err)
+			}
+			events = append(events, event)
+		}
+		if last {
+			break
+		}
+	}
+	return events, nil
+}
+
+// Passes the events to send keeping the pauses between them, divided by
+// speed. Speed 2 plays the game twice as fast as it was recorded.
+// Stops at the first error returned by send.
+func Play(events []*Event, speed float64, send func(*Event) error) error {
+	if speed <= 0 {
+		return fmt.Errorf("Invalid speed %g", speed)
+	}
+
+	for i, event := range events {
+		if i > 0 && event.Time > events[i-1].Time {
+			pause := time.Duration(event.Time-events[i-1].Time) * time.Millisecond
+			clock.Sleep(time.Duration(float64(pause) / speed))
+		}
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
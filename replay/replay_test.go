@@ -0,0 +1,89 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"warcluster/clock"
+)
+
+var epoch = time.Date(2014, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func TestRecordAndRead(t *testing.T) {
+	fake := clock.NewFake(epoch)
+	defer clock.Set(clock.Set(fake))
+
+	var log bytes.Buffer
+	recorder := NewRecorder(&log)
+	recorder.Record(Command, "gophie", map[string]string{"Command": "start_mission"})
+	fake.Advance(time.Second)
+	recorder.Record(OwnerChange, "gophie", &OwnerChangeData{"GOP6724", "", "gophie"})
+
+	if lines := strings.Count(log.String(), "\n"); lines != 2 {
+		t.Fatalf("%d lines have been written instead of 2:\n%s", lines, log.String())
+	}
+
+	events, err := Read(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Read %d events instead of 2", len(events))
+	}
+	if events[0].Type != Command || events[0].Player != "gophie" || events[0].Time != epoch.UnixNano()/1e6 {
+		t.Errorf("The command has been read as %#v", events[0])
+	}
+	if events[1].Time-events[0].Time != 1000 {
+		t.Errorf("The events are %dms apart", events[1].Time-events[0].Time)
+	}
+	if string(events[1].Data) != `{"Planet":"GOP6724","From":"","To":"gophie"}` {
+		t.Errorf("The owner change has been read as %s", events[1].Data)
+	}
+
+	if _, err := Read(strings.NewReader("{\"Time\": \"soon\"}\n{\"Time\": 1}\n")); err == nil {
+		t.Error("Broken log has been read")
+	}
+	events, err = Read(strings.NewReader("{\"Time\": 1}\n{\"Time\": 2, \"Ty"))
+	if err != nil || len(events) != 1 {
+		t.Errorf("Read %d events of a log cut short: %v", len(events), err)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	fake := clock.NewFake(epoch)
+	defer clock.Set(clock.Set(fake))
+
+	events := []*Event{
+		{Time: 1000, Type: Command},
+		{Time: 5000, Type: MissionLaunched},
+		{Time: 5000, Type: AreaTransfer},
+		{Time: 9000, Type: Battle},
+	}
+
+	if err := Play(events, 0, nil); err == nil {
+		t.Error("The game has been played at zero speed")
+	}
+
+	played := make(chan time.Time, len(events))
+	done := make(chan error, 1)
+	clock.Go(func(c clock.Clock) {
+		done <- Play(events, 2, func(event *Event) error {
+			played <- c.Now()
+			return nil
+		})
+	})
+
+	for fake.WaitIdle(time.Second) == nil && fake.Step() {
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	for i, offset := range []time.Duration{0, 2 * time.Second, 2 * time.Second, 4 * time.Second} {
+		if at := <-played; !at.Equal(epoch.Add(offset)) {
+			t.Errorf("Event %d has been played at %s", i, at)
+		}
+	}
+}
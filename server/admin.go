@@ -0,0 +1,189 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"warcluster/config"
+	"warcluster/entities"
+)
+
+// ConfigLoader reads the config again when a reload is requested.
+type ConfigLoader func() (config.Config, error)
+
+var configLoader ConfigLoader
+
+// Sets the function used to read the config on reload.
+func SetConfigLoader(loader ConfigLoader) {
+	configLoader = loader
+}
+
+// Checks the admin token of the request. It could be given either as
+// `Authorization: Bearer <token>` or as a `token` query parameter.
+// All admin endpoints are disabled while there is no token in the config.
+func isAdmin(request *http.Request) bool {
+	if cfg.Admin.Token == "" {
+		return false
+	}
+
+	token := request.URL.Query().Get("token")
+	if header := request.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Admin.Token)) == 1
+}
+
+// Dumps the effective config with all secrets redacted.
+func adminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	effective := cfg.Redacted()
+	effective.Entities = *entities.Settings()
+	result, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(result)
+}
+
+// Lists the running bots on GET, spawns one on POST and despawns one on
+// DELETE. The bot is given with the `name` query parameter and its
+// `universe`, if it isn't the default one. POST needs `strategy` as well
+// and optionally the `race` of new bots.
+func adminBotsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	switch r.Method {
+	case "GET":
+		result, err := json.Marshal(bots.list())
+		if err != nil {
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(result)
+	case "POST":
+		u, err := findUniverse(query.Get("universe"))
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		race, _ := strconv.ParseUint(query.Get("race"), 10, 8)
+		if _, err := bots.spawn(u, query.Get("name"), query.Get("strategy"), uint8(race)); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		fmt.Fprintln(w, "Bot spawned.")
+	case "DELETE":
+		u, err := findUniverse(query.Get("universe"))
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		if err := bots.despawn(u, query.Get("name")); err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		fmt.Fprintln(w, "Bot despawned.")
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+}
+
+// Reloads the gameplay settings on POST.
+func adminReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method Not Allowed", 405)
+		return
+	}
+
+	if err := Reload(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	fmt.Fprintln(w, "Settings reloaded.")
+}
+
+// Reads the config again and applies the new gameplay settings.
+func Reload() error {
+	if configLoader == nil {
+		return errors.New("Reloading is not configured")
+	}
+
+	newCfg, err := configLoader()
+	if err != nil {
+		return err
+	}
+	return ReloadConfig(newCfg)
+}
+
+// Applies the gameplay settings of the given config and sends the new
+// server params to everyone online. Only [entities] and the settings of
+// the universes could be changed at runtime and even there some of the
+// settings are fixed.
+func ReloadConfig(newCfg config.Config) error {
+	if !reflect.DeepEqual(cfg.Server, newCfg.Server) ||
+		!reflect.DeepEqual(cfg.Database, newCfg.Database) ||
+		!reflect.DeepEqual(cfg.Twitter, newCfg.Twitter) ||
+		!reflect.DeepEqual(cfg.Admin, newCfg.Admin) ||
+		!reflect.DeepEqual(cfg.Race, newCfg.Race) ||
+		!sameUniverses(cfg, newCfg) {
+		return errors.New("Only [entities] could be changed without a restart")
+	}
+
+	// All universes are checked before any of them is changed, so they
+	// are either reloaded together or not at all
+	all := append([]*Universe{defaultUniverse}, startedUniverses()...)
+	settings := make([]config.Entities, len(all))
+	for i, u := range all {
+		var err error
+		if settings[i], err = u.settingsIn(newCfg); err != nil {
+			return err
+		}
+	}
+	for i, u := range all {
+		u.applySettings(settings[i])
+	}
+	log.Println("Gameplay settings reloaded.")
+	return nil
+}
+
+// Checks if both configs have the same universes in the same databases
+// with the same tournaments. Only their settings could be different.
+func sameUniverses(a, b config.Config) bool {
+	if len(a.Universe) != len(b.Universe) {
+		return false
+	}
+	for id, universe := range a.Universe {
+		other, ok := b.Universe[id]
+		if !ok || other.Database != universe.Database || other.ReplayLog != universe.ReplayLog {
+			return false
+		}
+		if !other.TournamentStart.Equal(universe.TournamentStart) ||
+			other.TournamentDuration != universe.TournamentDuration ||
+			other.TournamentWin != universe.TournamentWin {
+			return false
+		}
+	}
+	return true
+}
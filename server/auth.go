@@ -17,21 +17,22 @@ import (
 // This function is called from the message handler to parse the first message for every new connection.
 // It check for existing user in the DB and logs him if the password is correct.
 // If the user is new he is initiated and a new home planet nad solar system are generated.
-func login(ws *websocket.Conn) (*Client, response.Responser, error) {
-	player, twitter, err := authenticate(ws)
+func (u *Universe) login(ws *websocket.Conn) (*Client, response.Responser, error) {
+	player, twitter, err := u.authenticate(ws)
 	if err != nil {
 		return nil, response.NewLoginFailed(), err
 	}
 
 	client := NewClient(ws, player, twitter)
-	homePlanetEntity, err := entities.Get(player.HomePlanet)
+	client.universe = u
+	homePlanetEntity, err := u.World().Get(player.HomePlanet)
 	if err != nil {
 		return nil, nil, errors.New("Player's home planet is missing!")
 	}
 	homePlanet := homePlanetEntity.(*entities.Planet)
 
 	loginSuccess := response.NewLoginSuccess(player, homePlanet)
-	planetEntities := entities.Find("planet.*")
+	planetEntities := u.World().Find("planet.*")
 	planets := make([]*entities.Planet, 0, len(planetEntities))
 	sites := make([]voronoi.Vertex, 0, len(planetEntities))
 	x0, xn, y0, yn := 0.0, 0.0, 0.0, 0.0
@@ -92,7 +93,7 @@ func FetchSetupData(ws *websocket.Conn) (*entities.SetupData, error) {
 // 3.1.Create a new sun with GenerateSun
 // 3.2.Choose home planet from the newly created solar sysitem.
 // 3.3.Create a reccord of the new player and start comunication.
-func authenticate(ws *websocket.Conn) (*entities.Player, *anaconda.TwitterApi, error) {
+func (u *Universe) authenticate(ws *websocket.Conn) (*entities.Player, *anaconda.TwitterApi, error) {
 	var (
 		nickname  string
 		twitterId string
@@ -121,7 +122,7 @@ func authenticate(ws *websocket.Conn) (*entities.Player, *anaconda.TwitterApi, e
 		}
 	}
 
-	serverParamsMessage := response.NewServerParams()
+	serverParamsMessage := response.NewServerParams(u.World())
 	if err = websocket.JSON.Send(ws, &serverParamsMessage); err != nil {
 		return nil, nil, err
 	}
@@ -129,15 +130,26 @@ func authenticate(ws *websocket.Conn) (*entities.Player, *anaconda.TwitterApi, e
 	nickname = request.Username
 	twitterId = request.TwitterID
 
-	entity, _ := entities.Get(fmt.Sprintf("player.%s", nickname))
+	entity, _ := u.World().Get(fmt.Sprintf("player.%s", nickname))
 	if entity == nil {
 		setupData, err = FetchSetupData(ws)
 		if err != nil {
 			return nil, nil, err
 		}
-		player = register(setupData, nickname, twitterId, twitter)
+		player = u.register(setupData, nickname, twitterId, twitter)
 	} else {
 		player = entity.(*entities.Player)
+		if player.NPC {
+			return nil, nil, errors.New("This player is controlled by the server")
+		}
+		home, err := u.World().Get(player.HomePlanet)
+		if err != nil {
+			// A new season has started since the last login
+			player = u.placeAgain(player, twitter)
+		} else if home.(*entities.Planet).Owner != player.Username && u.canRespawn() == nil {
+			// The home planet has been taken since the last login
+			player = u.placeAgain(player, twitter)
+		}
 	}
 	return player, twitter, nil
 }
@@ -148,28 +160,58 @@ func authenticate(ws *websocket.Conn) (*entities.Player, *anaconda.TwitterApi, e
 // - Create a new sun with GenerateSun.
 // - Choose home planet from the newly created solar sysitem.
 // - Create a reccord of the new player and start comunication.
-func register(setupData *entities.SetupData, nickname, twitterId string, twitterApi *anaconda.TwitterApi) *entities.Player {
-	friendsSuns := fetchFriendsSuns(nickname, twitterApi)
-	sun := entities.GenerateSun(nickname, friendsSuns, setupData)
-	planets, homePlanet := entities.GeneratePlanets(nickname, sun)
+func (u *Universe) register(setupData *entities.SetupData, nickname, twitterId string, twitterApi *anaconda.TwitterApi) *entities.Player {
+	sun, planets, homePlanet := u.generateSolarSystem(nickname, setupData, twitterApi)
 	player := entities.CreatePlayer(nickname, twitterId, homePlanet, setupData)
+	u.settle(player, sun, planets, homePlanet)
+	return player
+}
 
+// Places the player again in a new solar system with the race and sun
+// they have chosen at first, once their home planet is gone along with
+// the season or taken by someone else. Only their home planet, screen
+// position and newbie protection change, everything else is kept.
+func (u *Universe) placeAgain(player *entities.Player, twitterApi *anaconda.TwitterApi) *entities.Player {
+	setupData := &entities.SetupData{Race: player.RaceID, SunTextureId: player.SunTextureId}
+	sun, planets, homePlanet := u.generateSolarSystem(player.Username, setupData, twitterApi)
+	player.MoveHome(homePlanet)
+	u.settle(player, sun, planets, homePlanet)
+	return player
+}
+
+// Generates a new solar system of the player near the suns of their
+// twitter friends. Nothing is saved yet.
+func (u *Universe) generateSolarSystem(nickname string, setupData *entities.SetupData, twitterApi *anaconda.TwitterApi) (*entities.Sun, []*entities.Planet, *entities.Planet) {
+	world := u.World()
+	friendsSuns := u.fetchFriendsSuns(nickname, twitterApi)
+	sun := world.GenerateSun(nickname, friendsSuns, setupData)
+	planets, homePlanet := world.GeneratePlanets(nickname, sun)
+	return sun, planets, homePlanet
+}
+
+// Saves the player along with their new solar system, tells everyone
+// about it and puts them on the leaderboard.
+func (u *Universe) settle(player *entities.Player, sun *entities.Sun, planets []*entities.Planet, homePlanet *entities.Planet) {
+	world := u.World()
 	for _, planet := range planets {
-		entities.Save(planet)
-		clients.Broadcast(planet)
+		world.Save(planet)
+		u.Clients().Broadcast(planet)
 	}
 
-	entities.Save(player)
-	entities.Save(sun)
-
-	clients.Broadcast(sun)
-	leaderBoard.Add(&leaderboard.Player{
-		Username:   player.Username,
-		RaceId:     player.RaceID,
-		HomePlanet: homePlanet.Name,
-		Planets:    1,
-	})
-	return player
+	world.Save(player)
+	world.Save(sun)
+
+	u.Clients().Broadcast(sun)
+	energyPerMinute := world.PlanetProduction(homePlanet.Size, true).EnergyPerMinute
+	if !u.Leaderboard().Respawn(player.Username, homePlanet.Name, energyPerMinute) {
+		u.Leaderboard().Add(&leaderboard.Player{
+			Username:        player.Username,
+			RaceId:          player.RaceID,
+			HomePlanet:      homePlanet.Name,
+			Planets:         1,
+			EnergyPerMinute: energyPerMinute,
+		})
+	}
 }
 
 // Returns a slice with twitter ids of the given user's friends
@@ -204,14 +246,14 @@ func fetchTwitterFriends(screenName string, api *anaconda.TwitterApi) ([]string,
 }
 
 // Returns a slice of friend's suns
-func fetchFriendsSuns(twitterName string, api *anaconda.TwitterApi) (suns []*entities.Sun) {
+func (u *Universe) fetchFriendsSuns(twitterName string, api *anaconda.TwitterApi) (suns []*entities.Sun) {
 	friendsNames, twitterErr := fetchTwitterFriends(twitterName, api)
 	if twitterErr != nil {
 		return
 	}
 
 	for _, name := range friendsNames {
-		playerEntity, err := entities.Get(fmt.Sprintf("player.%s", name))
+		playerEntity, err := u.World().Get(fmt.Sprintf("player.%s", name))
 		if playerEntity == nil || err != nil {
 			continue
 		}
@@ -221,7 +263,7 @@ func fetchFriendsSuns(twitterName string, api *anaconda.TwitterApi) (suns []*ent
 			continue
 		}
 
-		if friendSun, sunErr := entities.Get(player.Sun()); sunErr == nil {
+		if friendSun, sunErr := u.World().Get(player.Sun()); sunErr == nil {
 			suns = append(suns, friendSun.(*entities.Sun))
 		}
 	}
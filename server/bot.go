@@ -0,0 +1,268 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/server/response"
+)
+
+// How much of the universe around its home planet a bot looks at.
+var botResolution = []uint64{20000, 20000}
+
+// Strategy decides what a bot does. It is given what the bot sees after
+// every scope of view and returns the requests the bot sends, exactly as
+// a websocket client would send them.
+type Strategy interface {
+	Think(player *entities.Player, view *BotView) []*Request
+}
+
+// BotView is everything a bot knows about the universe. Planets are
+// sanitized just like the ones sent to the players, so bots can't cheat.
+type BotView struct {
+	Planets  map[string]*entities.PlanetPacket
+	Missions map[string]*entities.Mission
+}
+
+// All known strategies, keyed by the name used to spawn bots with them.
+var strategies = map[string]func() Strategy{
+	"greedy": func() Strategy { return new(greedyStrategy) },
+}
+
+// Makes a new strategy available for the bots.
+func RegisterStrategy(name string, factory func() Strategy) {
+	strategies[name] = factory
+}
+
+// Bot is a player driven by a strategy instead of a websocket.
+type Bot struct {
+	Username string
+	Strategy string
+	Universe string `json:",omitempty"` // empty in the default universe
+	client   *Client
+	strategy Strategy
+	stop     chan struct{}
+}
+
+// Keeps all bots running in this server, keyed by botKey.
+type botPool struct {
+	mutex sync.Mutex
+	bots  map[string]*Bot
+}
+
+var bots = botPool{bots: make(map[string]*Bot)}
+
+// Returns the key of the bot with the given username in the universe.
+func botKey(u *Universe, username string) string {
+	if u == nil {
+		return username
+	}
+	return fmt.Sprintf("%s/%s", u.ID, username)
+}
+
+// Starts a bot with the given strategy in the universe. New bots are
+// registered just like new players, but nobody could log in as them.
+// A bot, which has been despawned, continues with the empire it had.
+func (b *botPool) spawn(u *Universe, username, strategyName string, race uint8) (*Bot, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(username) < 3 {
+		return nil, errors.New("Bot names need at least 3 characters")
+	}
+	factory, ok := strategies[strategyName]
+	if !ok {
+		return nil, fmt.Errorf("Unknown strategy %q", strategyName)
+	}
+	if _, ok := b.bots[botKey(u, username)]; ok {
+		return nil, fmt.Errorf("Bot %s is already running", username)
+	}
+
+	var player *entities.Player
+	entity, _ := u.World().Get(fmt.Sprintf("player.%s", username))
+	if entity == nil {
+		setupData := &entities.SetupData{Race: race}
+		if err := setupData.Validate(); err != nil {
+			return nil, err
+		}
+		player = u.register(setupData, username, "", nil)
+		player.NPC = true
+		u.World().Save(player)
+	} else if player = entity.(*entities.Player); !player.NPC {
+		return nil, fmt.Errorf("%s is not a bot", username)
+	}
+
+	client := NewClient(nil, player, nil)
+	client.codec = new(botCodec)
+	client.universe = u
+	bot := &Bot{
+		Username: username,
+		Strategy: strategyName,
+		client:   client,
+		strategy: factory(),
+		stop:     make(chan struct{}),
+	}
+	if u != nil {
+		bot.Universe = u.ID
+	}
+	b.bots[botKey(u, username)] = bot
+
+	u.Clients().Add(client)
+	player.UpdateSpyReports()
+	clock.Go(bot.run)
+	log.Printf("Bot %s has been spawned with the %s strategy", username, strategyName)
+	return bot, nil
+}
+
+// Stops the bot in the universe. Its planets and missions stay there.
+func (b *botPool) despawn(u *Universe, username string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	bot, ok := b.bots[botKey(u, username)]
+	if !ok {
+		return fmt.Errorf("Bot %s is not running", username)
+	}
+	b.stop(bot)
+	return nil
+}
+
+// Stops all bots playing in the universe, so they could be spawned again
+// once it has started over.
+func (b *botPool) despawnAll(u *Universe) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, bot := range b.bots {
+		if bot.client.universe == u {
+			b.stop(bot)
+		}
+	}
+}
+
+func (b *botPool) stop(bot *Bot) {
+	close(bot.stop)
+	bot.client.universe.Clients().Remove(bot.client)
+	delete(b.bots, botKey(bot.client.universe, bot.Username))
+	log.Printf("Bot %s has been despawned", bot.Username)
+}
+
+// Returns all running bots, sorted by username.
+func (b *botPool) list() []*Bot {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	names := make([]string, 0, len(b.bots))
+	for name := range b.bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*Bot, 0, len(names))
+	for _, name := range names {
+		result = append(result, b.bots[name])
+	}
+	return result
+}
+
+// Thinks every Settings().BotInterval seconds until the bot is despawned.
+func (b *Bot) run(c clock.Clock) {
+	for {
+		b.think()
+		timer := c.NewTimer(b.client.universe.World().Settings().BotInterval * time.Second)
+		select {
+		case <-b.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Looks around the home planet and sends whatever the strategy decides.
+func (b *Bot) think() {
+	entity, err := b.client.universe.World().Get(b.client.Player.HomePlanet)
+	if err != nil {
+		log.Printf("Bot %s has no home planet: %s", b.Username, err)
+		return
+	}
+	home, ok := entity.(*entities.Planet)
+	if !ok {
+		log.Printf("Bot %s has no home planet: %s is not a planet", b.Username, b.client.Player.HomePlanet)
+		return
+	}
+
+	b.send(&Request{
+		Command:    "scope_of_view",
+		Position:   home.Position,
+		Resolution: botResolution,
+	})
+
+	view := b.client.codec.(*botCodec).view()
+	if view == nil {
+		return
+	}
+	for _, request := range b.strategy.Think(b.client.Player, view) {
+		b.send(request)
+	}
+}
+
+// Handles the request the very same way requests from websockets are.
+// Bots run on their own, so a panicking handler takes down only the
+// request and not the whole server.
+func (b *Bot) send(request *Request) {
+	defer func() {
+		if panicked := recover(); panicked != nil {
+			log.Println(fmt.Sprintf("Bot %s: %s: %s\n\nStacktrace:\n\n%s", b.Username, request.Command, panicked, debug.Stack()))
+		}
+	}()
+	request.Client = b.client
+	action, err := ParseRequest(request)
+	if err == nil {
+		err = action(request)
+	}
+	if err != nil {
+		log.Printf("Bot %s: %s: %s", b.Username, request.Command, err)
+		return
+	}
+	recordCommand(request)
+}
+
+// botCodec is the "websocket" of the bots. It keeps the latest scope of
+// view and drops everything else.
+type botCodec struct {
+	mutex  sync.Mutex
+	latest *BotView
+}
+
+func (c *botCodec) Receive(ws *websocket.Conn, v interface{}) error {
+	return errors.New("Bots don't receive anything")
+}
+
+func (c *botCodec) Send(ws *websocket.Conn, v interface{}) error {
+	message, ok := v.(*response.Responser)
+	if !ok {
+		return nil
+	}
+	if scope, ok := (*message).(*response.ScopeOfView); ok {
+		c.mutex.Lock()
+		c.latest = &BotView{Planets: scope.Planets, Missions: scope.Missions}
+		c.mutex.Unlock()
+	}
+	return nil
+}
+
+func (c *botCodec) view() *BotView {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.latest
+}
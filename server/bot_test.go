@@ -0,0 +1,173 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/entities"
+)
+
+func packet(name, owner string, x float64, ships int32, spied bool) *entities.PlanetPacket {
+	return &entities.PlanetPacket{
+		Planet: entities.Planet{
+			Name:      name,
+			Position:  vec2d.New(x, 0),
+			Owner:     owner,
+			ShipCount: ships,
+		},
+		IsSpied: spied,
+	}
+}
+
+func TestGreedyStrategy(t *testing.T) {
+	bot := &entities.Player{Username: "botty"}
+	view := &BotView{
+		Planets: map[string]*entities.PlanetPacket{
+			"planet.BOT1230": packet("BOT1230", "botty", 0, 100, false),
+			"planet.BOT1231": packet("BOT1231", "botty", 5000, 10, false),
+			"planet.GOP6721": packet("GOP6721", "", 100, -1, false),
+			"planet.GOP6722": packet("GOP6722", "gophie", 200, 50, true),
+		},
+		Missions: map[string]*entities.Mission{},
+	}
+
+	requests := new(greedyStrategy).Think(bot, view)
+	if len(requests) != 1 || requests[0].Type != "Spy" || requests[0].EndPlanet != "planet.GOP6721" {
+		t.Fatalf("The bot should spy the nearest planet, but sends %#v", requests)
+	}
+	if requests[0].StartPlanets[0] != "planet.BOT1230" {
+		t.Errorf("The bot spies from %s, which has too few ships", requests[0].StartPlanets[0])
+	}
+
+	spies := &entities.Mission{Player: "botty"}
+	spies.Target.Name = "GOP6721"
+	view.Missions["mission.1_BOT1230"] = spies
+
+	requests = new(greedyStrategy).Think(bot, view)
+	if len(requests) != 1 || requests[0].Type != "Attack" || requests[0].EndPlanet != "planet.GOP6722" {
+		t.Fatalf("The bot should attack the weak spied planet, but sends %#v", requests)
+	}
+
+	view.Planets["planet.GOP6722"].ShipCount = 90
+	if requests = new(greedyStrategy).Think(bot, view); len(requests) != 0 {
+		t.Errorf("The bot attacks a stronger planet with %#v", requests)
+	}
+}
+
+func TestSpawnBot(t *testing.T) {
+	if _, err := bots.spawn(nil, "botty", "clueless", 0); err == nil {
+		t.Error("Bot with unknown strategy has been spawned")
+	}
+
+	bot, err := bots.spawn(nil, "botty", "greedy", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bots.despawn(nil, "botty")
+
+	entity, err := entities.Get("player.botty")
+	if err != nil || !entity.(*entities.Player).NPC {
+		t.Errorf("Bot has not been registered as an NPC: %#v", entity)
+	}
+	if _, err := clients.Player("botty"); err != nil {
+		t.Error("Bot is not online")
+	}
+	if _, err := bots.spawn(nil, "botty", "greedy", 0); err == nil {
+		t.Error("Bot has been spawned twice")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for bot.client.codec.(*botCodec).view() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if view := bot.client.codec.(*botCodec).view(); view == nil || len(view.Planets) == 0 {
+		t.Error("Bot doesn't see its own solar system")
+	}
+
+	if err := bots.despawn(nil, "botty"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clients.Player("botty"); err == nil {
+		t.Error("Despawned bot is still online")
+	}
+	if err := bots.despawn(nil, "botty"); err == nil {
+		t.Error("Bot has been despawned twice")
+	}
+}
+
+func TestDespawnAllBots(t *testing.T) {
+	if _, err := bots.spawn(nil, "seasoned", "greedy", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	elsewhere := newTestUniverse(t, *entities.Settings())
+	bot, err := bots.spawn(elsewhere, "seasoned", "greedy", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := elsewhere.World().Get("player.seasoned"); err != nil || bot.Universe != elsewhere.ID {
+		t.Errorf("The bot has not been registered in its universe: %v", err)
+	}
+	if _, err := elsewhere.Clients().Player("seasoned"); err != nil {
+		t.Error("The bot is not online in its universe")
+	}
+
+	bots.despawnAll(elsewhere)
+	if _, err := elsewhere.Clients().Player("seasoned"); err == nil {
+		t.Error("The bot is still online in its universe")
+	}
+	if _, err := clients.Player("seasoned"); err != nil {
+		t.Error("A bot of another universe has been despawned")
+	}
+
+	bots.despawnAll(defaultUniverse)
+	if _, err := clients.Player("seasoned"); err == nil {
+		t.Error("The bot is still online")
+	}
+	if _, err := bots.spawn(nil, "seasoned", "greedy", 0); err != nil {
+		t.Errorf("The bot could not be spawned again: %s", err)
+	}
+	bots.despawn(nil, "seasoned")
+}
+
+func TestBotSurvivesPanics(t *testing.T) {
+	bot := &Bot{
+		Username: "clumsy",
+		client:   NewClient(nil, &entities.Player{Username: "clumsy"}, nil),
+	}
+
+	// The client has no codec, so sending anything back panics
+	bot.send(&Request{Command: "history"})
+}
+
+func TestAdminBots(t *testing.T) {
+	defer func(token string) {
+		cfg.Admin.Token = token
+	}(cfg.Admin.Token)
+	cfg.Admin.Token = "secret"
+
+	request := func(method, query string) int {
+		req, _ := http.NewRequest(method, fmt.Sprintf("/admin/bots?token=secret&%s", query), nil)
+		w := httptest.NewRecorder()
+		adminBotsHandler(w, req)
+		return w.Code
+	}
+
+	if code := request("POST", "name=adminbot&strategy=greedy"); code != 200 {
+		t.Errorf("Spawning a bot responded with %d", code)
+	}
+	if code := request("GET", ""); code != 200 {
+		t.Errorf("Listing the bots responded with %d", code)
+	}
+	if code := request("DELETE", "name=adminbot"); code != 200 {
+		t.Errorf("Despawning a bot responded with %d", code)
+	}
+	if code := request("DELETE", "name=adminbot"); code != 404 {
+		t.Errorf("Despawning a missing bot responded with %d", code)
+	}
+}
@@ -22,9 +22,13 @@ type Codec interface {
 // This is one of them. The purpouse of the Client struct is to hold the server(connection) information.
 // 1.Session holds the curent player session socket for comunication.
 // 2.Player is a pointer to the player struct for easy access.
+// Spectators have no player, they are known only by their area member.
+// Clients play in the default universe, unless another one is set.
 type Client struct {
 	Conn        *websocket.Conn
 	Player      *entities.Player
+	universe    *Universe
+	spectator   string
 	areas       map[string]struct{}
 	poolElement *list.Element
 	stateChange *response.StateChange
@@ -76,14 +80,22 @@ func (c *Client) pushStateChange(entity entities.Entity) {
 	}
 }
 
+// Returns the key the client is known by in the area sets.
+func (c *Client) areaMember() string {
+	if c.spectator != "" {
+		return c.spectator
+	}
+	return c.Player.Key()
+}
+
 // Moves the client to another area
 func (c *Client) MoveToAreas(areaSlice []string) {
-	conn := db.Pool.Get()
+	conn := c.universe.World().Conn()
 	defer conn.Close()
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	player := c.Player.Key()
+	player := c.areaMember()
 	// Create map of the new areas in order
 	// to search in them more easily
 	areas := make(map[string]struct{})
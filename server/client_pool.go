@@ -16,15 +16,21 @@ import (
 )
 
 // Thread-safe pool of all clients, with opened sockets.
+// Spectators are kept aside, keyed by their area member, so they are
+// never mistaken for players. The area sets are the ones of the world,
+// the clients play in.
 type ClientPool struct {
-	mutex  sync.Mutex
-	pool   map[string]*list.List
-	ticker *time.Ticker
+	mutex      sync.Mutex
+	world      *entities.World
+	pool       map[string]*list.List
+	spectators map[string]*Client
+	ticker     *time.Ticker
 }
 
 func NewClientPool(ticker time.Duration) *ClientPool {
 	cp := new(ClientPool)
 	cp.pool = make(map[string]*list.List)
+	cp.spectators = make(map[string]*Client)
 	cp.ticker = time.NewTicker(ticker * time.Millisecond)
 	go cp.runStateChangeCycle()
 	return cp
@@ -47,6 +53,9 @@ func (cp *ClientPool) runStateChangeCycle() {
 				element.Value.(*Client).sendStateChange()
 			}
 		}
+		for _, spectator := range cp.spectators {
+			spectator.sendStateChange()
+		}
 	}
 }
 
@@ -75,7 +84,7 @@ func (cp *ClientPool) Add(client *Client) {
 func (cp *ClientPool) Remove(client *Client) {
 	cp.mutex.Lock()
 	defer cp.mutex.Unlock()
-	conn := db.Pool.Get()
+	conn := cp.world.Conn()
 	defer conn.Close()
 
 	playerInPool, ok := cp.pool[client.Player.Username]
@@ -91,6 +100,29 @@ func (cp *ClientPool) Remove(client *Client) {
 	}
 }
 
+// Adds the given spectator to the pool.
+func (cp *ClientPool) AddSpectator(client *Client) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	cp.spectators[client.spectator] = client
+}
+
+// Removes the spectator from the pool and all areas it watches.
+func (cp *ClientPool) RemoveSpectator(client *Client) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	conn := cp.world.Conn()
+	defer conn.Close()
+
+	if _, ok := cp.spectators[client.spectator]; ok {
+		delete(cp.spectators, client.spectator)
+		for area, _ := range client.areas {
+			db.Srem(conn, area, client.spectator)
+		}
+	}
+}
+
 // Broadcasts state change of an entity to all interested parties
 func (cp *ClientPool) Broadcast(entity entities.Entity) {
 	defer func() {
@@ -98,7 +130,7 @@ func (cp *ClientPool) Broadcast(entity entities.Entity) {
 			return
 		}
 	}()
-	conn := db.Pool.Get()
+	conn := cp.world.Conn()
 	defer conn.Close()
 
 	members, err := db.Smembers(conn, entity.AreaSet())
@@ -108,6 +140,12 @@ func (cp *ClientPool) Broadcast(entity entities.Entity) {
 	}
 
 	for _, member := range members {
+		if spectator, ok := cp.spectators[member]; ok {
+			if _, in := spectator.areas[entity.AreaSet()]; in {
+				spectator.pushStateChange(entity)
+			}
+			continue
+		}
 		if !strings.HasPrefix(member, "player.") {
 			continue
 		}
@@ -154,3 +192,34 @@ func (cp *ClientPool) Send(player *entities.Player, response response.Responser)
 		client.Send(response)
 	}
 }
+
+// Sends the given response to every client in the pool, spectators
+// included.
+func (cp *ClientPool) SendAll(response response.Responser) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	for _, clients := range cp.pool {
+		for element := clients.Front(); element != nil; element = element.Next() {
+			element.Value.(*Client).Send(response)
+		}
+	}
+	for _, spectator := range cp.spectators {
+		spectator.Send(response)
+	}
+}
+
+// Closes the websockets of all players in the pool, so they have to log
+// in again. Bots and spectators stay.
+func (cp *ClientPool) DisconnectAll() {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	for _, clients := range cp.pool {
+		for element := clients.Front(); element != nil; element = element.Next() {
+			if client := element.Value.(*Client); client.Conn != nil {
+				client.Conn.Close()
+			}
+		}
+	}
+}
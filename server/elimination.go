@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"log"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/server/response"
+)
+
+// A player is eliminated once their home planet is taken, which happens
+// only after a siege (see entities.Settings().HomePlanetSiege). They keep
+// the rest of their planets and could come back in a new solar system.
+
+// Tells the player they have just lost their home planet to its new owner
+// and records it in the history of both.
+func (u *Universe) eliminate(player *entities.Player, home *entities.Planet) {
+	log.Printf("%s has taken the home planet %s of %s", home.Owner, home.Name, player.Username)
+	u.World().RecordHistory(player.Username, entities.HomePlanetLost, home.Name, home.Owner, 0)
+	u.World().RecordHistory(home.Owner, entities.HomePlanetTaken, home.Name, player.Username, 0)
+	u.Clients().Send(player, response.NewPlayerEliminated(home))
+}
+
+// Returns an error if eliminated players could not come back right now,
+// because they would stand again in a last home standing tournament.
+func (u *Universe) canRespawn() error {
+	t := u.Tournament()
+	if t != nil && t.WinCondition == config.WinLastHomeStanding && t.state(clock.Now()) == tournamentRunning {
+		return errors.New("Eliminated players can't come back until the tournament is over.")
+	}
+	return nil
+}
+
+// Places the eliminated player again in a new solar system and logs them
+// in there.
+func respawn(request *Request) error {
+	u := request.Client.universe
+	player := request.Client.Player
+	entity, err := u.World().Get(player.HomePlanet)
+	if err == nil && entity.(*entities.Planet).Owner == player.Username {
+		return errors.New("The player still owns their home planet.")
+	}
+	if err := u.checkTournament(); err != nil {
+		return err
+	}
+	if err := u.canRespawn(); err != nil {
+		return err
+	}
+
+	u.placeAgain(player, request.Client.twitter)
+	entity, err = u.World().Get(player.HomePlanet)
+	if err != nil {
+		return err
+	}
+	request.Client.Send(response.NewLoginSuccess(player, entity.(*entities.Planet)))
+	return nil
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/leaderboard"
+)
+
+func TestHomePlanetSiege(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 10, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.NewbieProtection = 0
+	settings.HomePlanetSiege = 3600
+	u := newTestUniverse(t, settings)
+	world := u.World()
+
+	defender := registerTestPlayer(u, "defender")
+	raider := registerTestPlayer(u, "raider")
+	client := testClient(u, defender)
+	u.Clients().Add(client)
+	defer u.Clients().Remove(client)
+
+	entity, _ := world.Get(raider.HomePlanet)
+	base := entity.(*entities.Planet)
+	base.SetShipCount(100000)
+	world.Save(base)
+
+	oldHome := defender.HomePlanet
+	home := func() *entities.Planet {
+		entity, _ := world.Get(oldHome)
+		planet := entity.(*entities.Planet)
+		planet.UpdateShipCount()
+		return planet
+	}
+	attack := func() {
+		raid, err := prepareMission(raider.HomePlanet, home(), &Request{
+			Client:       testClient(u, raider),
+			StartPlanets: []string{raider.HomePlanet},
+			EndPlanet:    oldHome,
+			Type:         "Attack",
+			Fleet:        10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if err := fake.WaitIdle(time.Second); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := world.Get(raid.Key()); err != nil {
+				return
+			}
+			fake.Step()
+		}
+	}
+
+	attack()
+	if planet := home(); planet.Owner != defender.Username || planet.ShipCount != 0 || planet.SiegedSince == 0 {
+		t.Fatalf("The home planet of %s has %d ships and is sieged since %d", planet.Owner, planet.ShipCount, planet.SiegedSince)
+	}
+	if err := respawn(&Request{Client: client}); err == nil {
+		t.Error("The player has respawned while owning their home planet")
+	}
+
+	fake.Advance(settings.HomePlanetSiege * time.Second / 2)
+	attack()
+	if planet := home(); planet.Owner != defender.Username || planet.SiegeRenewed == planet.SiegedSince {
+		t.Fatalf("The siege of the home planet of %s is not renewed", planet.Owner)
+	}
+	fake.Advance(settings.HomePlanetSiege * time.Second / 2)
+	attack()
+	if planet := home(); planet.Owner != raider.Username || planet.SiegedSince != 0 {
+		t.Fatalf("The home planet is owned by %s after the siege", planet.Owner)
+	}
+	eliminated := false
+	for _, message := range client.codec.(*fakeCodec).Messages {
+		eliminated = eliminated || strings.Contains(string(message), `"Command":"player_eliminated"`)
+	}
+	if !eliminated {
+		t.Error("The player is not told about the elimination")
+	}
+
+	standing := func(username string) *leaderboard.Player {
+		for _, player := range u.Leaderboard().Players() {
+			if player.Username == username {
+				return player
+			}
+		}
+		return nil
+	}
+	deadline := time.Now().Add(time.Second)
+	for standing(defender.Username).Eliminated == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if standing(defender.Username).Eliminated != 1 || standing(raider.Username).Eliminations != 1 {
+		t.Errorf("The leaderboard has not recorded the elimination")
+	}
+
+	vacationEnd := fake.Now().Add(-time.Hour).UnixNano() / 1e6
+	defender.VacationEnd = vacationEnd
+	world.Save(defender)
+	if err := respawn(&Request{Client: client}); err != nil {
+		t.Fatal(err)
+	}
+	entity, _ = world.Get(defender.Key())
+	if saved := entity.(*entities.Player); saved.HomePlanet != client.Player.HomePlanet || saved.VacationEnd != vacationEnd {
+		t.Errorf("The player is saved at %s with a vacation ended at %d", saved.HomePlanet, saved.VacationEnd)
+	}
+	entity, err := world.Get(client.Player.HomePlanet)
+	if err != nil || client.Player.HomePlanet == oldHome || entity.(*entities.Planet).Owner != defender.Username {
+		t.Fatalf("The player has respawned at %s: %v", client.Player.HomePlanet, err)
+	}
+	if home().Owner != raider.Username {
+		t.Errorf("The lost home planet has been given back to %s", home().Owner)
+	}
+	if u.Leaderboard().Len() != 2 || standing(defender.Username).HomePlanet != entity.(*entities.Planet).Name {
+		t.Errorf("The leaderboard has %d players after the respawn", u.Leaderboard().Len())
+	}
+}
+
+func TestRespawnInTournament(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 11, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	u := newTestUniverse(t, *entities.Settings())
+	u.HostTournament(NewTournament(fake.Now().Add(time.Hour), time.Hour, config.WinMostPlanets))
+	world := u.World()
+
+	player := registerTestPlayer(u, "contender")
+	raider := registerTestPlayer(u, "raider")
+	client := testClient(u, player)
+	eliminate := func() {
+		entity, _ := world.Get(player.HomePlanet)
+		home := entity.(*entities.Planet)
+		home.Owner = raider.Username
+		world.Save(home)
+	}
+
+	eliminate()
+	if err := respawn(&Request{Client: client}); err == nil || err.Error() != "The tournament hasn't started yet." {
+		t.Errorf("The player has respawned before the tournament: %v", err)
+	}
+	fake.Advance(time.Hour)
+	if err := respawn(&Request{Client: client}); err != nil {
+		t.Fatalf("The player could not respawn during the tournament: %s", err)
+	}
+	eliminate()
+	fake.Advance(time.Hour)
+	if err := respawn(&Request{Client: client}); err == nil || err.Error() != "The tournament is over." {
+		t.Errorf("The player has respawned after the tournament: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/config"
+	"warcluster/entities"
+)
+
+const (
+	greedyMinShips    = 20 // planets with fewer ships just wait
+	greedySpyFleet    = 5  // percent of the ships sent to spy
+	greedyAttackFleet = 80 // percent of the ships sent to attack
+)
+
+// greedyStrategy expands to the nearest planets it could capture. Every
+// planet with enough ships spies the nearest planet the bot doesn't own
+// and attacks it once the report shows it is weaker than the attack would
+// be. Home planets can't be captured, so they are left alone. Targets of
+// the bot's own missions are skipped until the missions are over.
+type greedyStrategy struct{}
+
+func (g *greedyStrategy) Think(player *entities.Player, view *BotView) []*Request {
+	var own, others []*entities.PlanetPacket
+	busy := make(map[string]bool)
+
+	for _, mission := range view.Missions {
+		if mission.Player == player.Username {
+			busy[mission.Target.Name] = true
+		}
+	}
+	for _, planet := range view.Planets {
+		if planet.Owner == player.Username {
+			own = append(own, planet)
+		} else if !planet.IsHome {
+			others = append(others, planet)
+		}
+	}
+	sort.Sort(packetsByName(own))
+	sort.Sort(packetsByName(others))
+
+	var requests []*Request
+	for _, source := range own {
+		if source.ShipCount < greedyMinShips {
+			continue
+		}
+
+		target := nearestPlanet(source.Position, others, busy)
+		if target == nil {
+			continue
+		}
+
+		request := &Request{
+			Command:      "start_mission",
+			StartPlanets: []string{source.Key()},
+			EndPlanet:    target.Key(),
+		}
+		defence := float64(target.ShipCount) * target.BuildingModifier(config.Defences)
+		switch {
+		case !target.IsSpied:
+			request.Type = "Spy"
+			request.Fleet = greedySpyFleet
+		case defence < float64(source.ShipCount*greedyAttackFleet/100):
+			request.Type = "Attack"
+			request.Fleet = greedyAttackFleet
+		default:
+			continue
+		}
+		busy[target.Name] = true
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// Returns the nearest of the planets, which is not busy.
+func nearestPlanet(position *vec2d.Vector, planets []*entities.PlanetPacket, busy map[string]bool) *entities.PlanetPacket {
+	var nearest *entities.PlanetPacket
+	var distance float64
+
+	for _, planet := range planets {
+		if busy[planet.Name] {
+			continue
+		}
+		if d := vec2d.GetDistance(position, planet.Position); nearest == nil || d < distance {
+			nearest = planet
+			distance = d
+		}
+	}
+	return nearest
+}
+
+// Sorts planets by name, so the bots are predictable.
+type packetsByName []*entities.PlanetPacket
+
+func (p packetsByName) Len() int           { return len(p) }
+func (p packetsByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p packetsByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
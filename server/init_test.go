@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"testing"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
@@ -27,7 +28,10 @@ var (
 
 func init() {
 	var cfg config.Config
-	cfg.Load()
+	if err := cfg.Load(); err != nil {
+		log.Fatal(err)
+	}
+	entities.ExportConfig(cfg)
 	db.InitPool(cfg.Database.Host, cfg.Database.Port, 13)
 	conn := db.Pool.Get()
 	defer conn.Close()
@@ -121,6 +125,31 @@ func NewFakeClient(player *entities.Player) *Client {
 	return client
 }
 
+// Creates a universe with the given settings on a database of its own.
+// Its leaderboard is ready, so players could be registered right away,
+// but nothing else is started.
+func newTestUniverse(t *testing.T, settings config.Entities) *Universe {
+	u, err := NewUniverse("test", db.NewMemoryPool(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.initLeaderboard(leaderboard.New())
+	return u
+}
+
+// Registers a new player in the universe with the default setup.
+func registerTestPlayer(u *Universe, username string) *entities.Player {
+	return u.register(&entities.SetupData{}, username, username, nil)
+}
+
+// Returns a client of the player in the universe, which keeps everything
+// sent to it, so handlers could be called with it directly.
+func testClient(u *Universe, player *entities.Player) *Client {
+	client := NewFakeClient(player)
+	client.universe = u
+	return client
+}
+
 type fakeCodec struct {
 	Messages  [][]byte
 	Marshal   func(v interface{}) (data []byte, payloadType byte, err error)
@@ -17,8 +17,16 @@ type searchResult struct {
 	Page     int
 }
 
+// The handlers below show the default universe, unless another one is
+// given with ?universe=<id>.
+
 func leaderboardPlayersHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	u, err := universeOfRequest(r)
+	if err != nil {
+		http.Error(w, "Universe Not Found", 404)
+		return
+	}
 	pageQuery, ok := r.URL.Query()["page"]
 	if !ok {
 		http.Error(w, "Bad Request", 400)
@@ -31,7 +39,7 @@ func leaderboardPlayersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	boardPage, err := leaderBoard.Page(page)
+	boardPage, err := u.Leaderboard().Page(page)
 	if err != nil {
 		http.Error(w, "Page Not Found", 404)
 		return
@@ -43,8 +51,13 @@ func leaderboardPlayersHandler(w http.ResponseWriter, r *http.Request) {
 
 func leaderboardRacesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	u, err := universeOfRequest(r)
+	if err != nil {
+		http.Error(w, "Universe Not Found", 404)
+		return
+	}
 
-	races, err := json.Marshal(leaderBoard.Races())
+	races, err := json.Marshal(u.Leaderboard().Races())
 	if err != nil {
 		http.Error(w, "Internal Server Error", 500)
 		return
@@ -65,13 +78,18 @@ func leaderboardRacesInfoHandler(w http.ResponseWriter, r *http.Request) {
 
 func searchHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	u, err := universeOfRequest(r)
+	if err != nil {
+		http.Error(w, "Universe Not Found", 404)
+		return
+	}
 	username, ok := r.URL.Query()["player"]
 	if !ok || len(username[0]) < 3 {
 		http.Error(w, "Bad Request", 400)
 		return
 	}
 
-	players, err := entities.GetList(fmt.Sprintf("player.%s*", username[0]))
+	players, err := u.World().GetList(fmt.Sprintf("player.%s*", username[0]))
 	if err != nil {
 		http.Error(w, "Internal Server Error", 500)
 		return
@@ -80,7 +98,7 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 
 	for _, player := range players {
 		username := player[7:]
-		page := math.Ceil(float64(leaderBoard.Place(username)+1) / 10)
+		page := math.Ceil(float64(u.Leaderboard().Place(username)+1) / 10)
 		result = append(result, searchResult{username, int(page)})
 	}
 
@@ -90,10 +108,18 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 
 // Initialize the leaderboard
 func InitLeaderboard(board *leaderboard.Leaderboard) {
+	defaultUniverse.initLeaderboard(board)
+}
+
+// Fills the given leaderboard with the players of the universe and makes
+// it the leaderboard of the universe.
+func (u *Universe) initLeaderboard(board *leaderboard.Leaderboard) {
 	log.Println("Initializing the leaderboard...")
 	allPlayers := make(map[string]*leaderboard.Player)
-	playerEntities := entities.Find("player.*")
-	planetEntities := entities.Find("planet.*")
+	players := make([]*leaderboard.Player, 0)
+	homePlanets := make(map[string]bool)
+	playerEntities := u.World().Find("player.*")
+	planetEntities := u.World().Find("planet.*")
 
 	for key, value := range cfg.Race {
 		board.AddRace(
@@ -111,13 +137,19 @@ func InitLeaderboard(board *leaderboard.Leaderboard) {
 			Planets:  0,
 		}
 		allPlayers[player.Username] = leaderboardPlayer
-		board.Add(leaderboardPlayer)
+		players = append(players, leaderboardPlayer)
 	}
 
 	for _, entity := range planetEntities {
 		planet, ok := entity.(*entities.Planet)
 
-		if !planet.HasOwner() || !ok {
+		if !ok {
+			continue
+		}
+		if planet.IsHome {
+			homePlanets[planet.Key()] = true
+		}
+		if !planet.HasOwner() {
 			continue
 		}
 
@@ -128,8 +160,21 @@ func InitLeaderboard(board *leaderboard.Leaderboard) {
 		}
 
 		player.Planets++
+		player.EnergyPerMinute += u.World().PlanetProduction(planet.Size, planet.IsHome).EnergyPerMinute
+	}
+
+	for i, player := range players {
+		// Players who haven't logged in since the season has ended are
+		// added once they are placed again
+		if homePlanets[playerEntities[i].(*entities.Player).HomePlanet] {
+			board.Add(player)
+		}
 	}
 	board.Sort()
 	board.RecountRacesPlanets()
-	leaderBoard = board
+	if u == nil {
+		leaderBoard = board
+	} else {
+		u.leaderBoard = board
+	}
 }
@@ -12,6 +12,7 @@ import (
 
 	"golang.org/x/net/websocket"
 
+	"warcluster/clock"
 	"warcluster/config"
 	"warcluster/leaderboard"
 	"warcluster/server/response"
@@ -49,11 +50,19 @@ func NewServer(host string, port uint16) *Server {
 func (s *Server) setupRoutes() {
 	once.Do(func() {
 		http.HandleFunc("/console", consoleHandler)
+		http.HandleFunc("/admin/bots", adminBotsHandler)
+		http.HandleFunc("/admin/config", adminConfigHandler)
+		http.HandleFunc("/admin/reload", adminReloadHandler)
+		http.HandleFunc("/admin/season", adminSeasonHandler)
 		http.HandleFunc("/leaderboard/players/", leaderboardPlayersHandler)
 		http.HandleFunc("/leaderboard/races/", leaderboardRacesHandler)
 		http.HandleFunc("/leaderboard/races/info/", leaderboardRacesInfoHandler)
 		http.HandleFunc("/search/", searchHandler)
+		http.HandleFunc("/seasons", seasonsHandler)
+		http.HandleFunc("/tournament/results", tournamentResultsHandler)
+		http.Handle("/spectate", websocket.Handler(SpectateHandle))
 		http.Handle("/universe", websocket.Handler(Handle))
+		http.Handle("/universe/", websocket.Handler(Handle))
 	})
 }
 
@@ -113,6 +122,7 @@ func consoleHandler(response http.ResponseWriter, request *http.Request) {
 // On the first received message from each connection the server will call the handler.
 // Add new session to the session pool, call the login func to validate the connection and
 // if the connection is valid enters "while true" state and uses ParseRequest to parse the requests.
+// The universe is chosen by the path: /universe/<id> or /universe for the default one.
 func Handle(ws *websocket.Conn) {
 	var request Request
 	defer func() {
@@ -123,7 +133,14 @@ func Handle(ws *websocket.Conn) {
 	}()
 	defer ws.Close()
 
-	client, logResponse, err := login(ws)
+	u, err := universeOfPath(ws.Request().URL.Path)
+	if err != nil {
+		websocket.JSON.Send(ws, response.NewError(err.Error()))
+		return
+	}
+	clients := u.Clients()
+
+	client, logResponse, err := u.login(ws)
 	if err != nil {
 		log.Print("Error in server.main.handler.login:", err.Error())
 		websocket.JSON.Send(ws, &logResponse)
@@ -133,6 +150,9 @@ func Handle(ws *websocket.Conn) {
 	defer clients.Remove(client)
 
 	clients.Send(client.Player, logResponse)
+	if status := u.tournamentStatus(clock.Now()); status != nil {
+		clients.Send(client.Player, status)
+	}
 
 	client.Player.UpdateSpyReports()
 	for {
@@ -154,6 +174,7 @@ func Handle(ws *websocket.Conn) {
 			clients.Send(client.Player, response.NewError(err.Error()))
 			continue
 		}
+		recordCommand(&request)
 	}
 }
 
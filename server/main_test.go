@@ -9,6 +9,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+
+	"warcluster/entities"
+	"warcluster/entities/db"
 )
 
 type ServerTest struct {
@@ -59,6 +62,63 @@ func (st *ServerTest) TestConsolePermissions() {
 	assert.Equal(st.T(), 404, w.Code)
 }
 
+func (st *ServerTest) TestAdminConfig() {
+	defer func(token string) {
+		cfg.Admin.Token = token
+	}(cfg.Admin.Token)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("http://%s/admin/config", st.server.Addr), nil)
+
+	cfg.Admin.Token = ""
+	w := httptest.NewRecorder()
+	adminConfigHandler(w, req)
+	assert.Equal(st.T(), 404, w.Code)
+
+	cfg.Admin.Token = "secret"
+	w = httptest.NewRecorder()
+	adminConfigHandler(w, req)
+	assert.Equal(st.T(), 404, w.Code)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	adminConfigHandler(w, req)
+	assert.Equal(st.T(), 200, w.Code)
+	assert.NotContains(st.T(), w.Body.String(), "secret")
+}
+
+func (st *ServerTest) TestReloadConfig() {
+	defer entities.ReloadSettings(*entities.Settings())
+
+	newCfg := cfg
+	newCfg.Entities = *entities.Settings()
+	newCfg.Entities.MissionSpeed = 42
+	assert.Nil(st.T(), ReloadConfig(newCfg))
+	assert.Equal(st.T(), int64(42), entities.Settings().MissionSpeed)
+
+	newCfg.Entities.AreaSize++
+	assert.NotNil(st.T(), ReloadConfig(newCfg))
+
+	newCfg = cfg
+	newCfg.Server.Port++
+	assert.NotNil(st.T(), ReloadConfig(newCfg))
+}
+
+func (st *ServerTest) TestReloadConfigOfAllUniverses() {
+	defer entities.ReloadSettings(*entities.Settings())
+
+	// The universe is missing from the config, so it can't be reloaded
+	u, err := NewUniverse("unlisted", db.NewMemoryPool(), *entities.Settings())
+	assert.Nil(st.T(), err)
+	assert.Nil(st.T(), u.Start())
+	defer u.Stop()
+
+	newCfg := cfg
+	newCfg.Entities = *entities.Settings()
+	newCfg.Entities.MissionSpeed = 42
+	assert.NotNil(st.T(), ReloadConfig(newCfg))
+	assert.NotEqual(st.T(), int64(42), entities.Settings().MissionSpeed)
+}
+
 func TestServerTestSuite(t *testing.T) {
 	suite.Run(t, new(ServerTest))
 }
@@ -5,7 +5,10 @@ import (
 	"log"
 	"time"
 
+	"warcluster/clock"
 	"warcluster/entities"
+	"warcluster/leaderboard"
+	"warcluster/replay"
 	"warcluster/server/response"
 
 	"github.com/Vladimiroff/vec2d"
@@ -14,14 +17,20 @@ import (
 // Spawns missionary for all mission records found
 // in the database when the server is started
 func SpawnDbMissions() {
-	for _, entity := range entities.Find("mission.*") {
+	defaultUniverse.spawnDbMissions()
+}
+
+// Spawns missionary for all mission records found in the database of the
+// universe.
+func (u *Universe) spawnDbMissions() {
+	for _, entity := range u.World().Find("mission.*") {
 		mission, ok := entity.(*entities.Mission)
 		if !ok {
 			log.Printf("Record %s does not seem to be a mission!?\n", mission.Key())
 		}
 
 		sourceKey := fmt.Sprintf("planet.%s", mission.Source.Name)
-		sourceEntity, err := entities.Get(sourceKey)
+		sourceEntity, err := u.World().Get(sourceKey)
 		if err != nil {
 			log.Printf("Can't find planet %s for mission %s!?\n", sourceKey, mission.Key())
 		}
@@ -34,14 +43,31 @@ func SpawnDbMissions() {
 			mission.Source.Name,
 			mission.Target.Name,
 		)
-		go StartMissionary(mission)
+		u.spawnMissionary(mission)
 	}
 }
 
+// Starts the missionary of the mission in a new goroutine of the clock.
+// Nothing is started while the season is ending, the mission is wiped
+// along with it.
+func (u *Universe) spawnMissionary(mission *entities.Mission) {
+	pool := u.Missionaries()
+	recall, ok := pool.add()
+	if !ok {
+		return
+	}
+	clock.Go(func(c clock.Clock) {
+		defer pool.done()
+		u.StartMissionary(c, mission, recall)
+	})
+}
+
 // StartMissionary is used when a call to initiate a new mission is rescived.
 // 1. When the delay ends the thread ends the mission calling EndMission
 // 2. The end of the mission is bradcasted to all clients and the mission entry is erased from the DB.
-func StartMissionary(mission *entities.Mission) {
+// The missionary travels on the given clock and stops right away once it
+// is called back through recall.
+func (u *Universe) StartMissionary(c clock.Clock, mission *entities.Mission, recall <-chan struct{}) {
 	var (
 		err             error
 		excessShips     int32
@@ -53,14 +79,15 @@ func StartMissionary(mission *entities.Mission) {
 		timeSlept       time.Duration
 	)
 
-	initialTimeSlept := time.Duration(time.Now().UnixNano()/1e6 - mission.StartTime)
+	initialTimeSlept := time.Duration(c.Now().UnixNano()/1e6 - mission.StartTime)
 	if initialTimeSlept > 0 {
 		timeSlept = initialTimeSlept
 	} else {
 		foundStartPoint = true
 	}
 
-	entities.Save(mission)
+	world := u.World()
+	world.Save(mission)
 	targetKey := fmt.Sprintf("planet.%s", mission.Target.Name)
 	for _, transferPoint := range mission.TransferPoints() {
 		if !foundStartPoint {
@@ -75,16 +102,24 @@ func StartMissionary(mission *entities.Mission) {
 
 		timeToSleep := transferPoint.TravelTime
 		timeSlept += timeToSleep
-		time.Sleep(timeToSleep * time.Millisecond)
+		if !sleepUnlessRecalled(c, timeToSleep*time.Millisecond, recall) {
+			return
+		}
 		mission.ChangeAreaSet(transferPoint.CoordinateAxis, transferPoint.Direction)
 
-		clients.Broadcast(mission)
+		u.Clients().Broadcast(mission)
+		u.recordAreaTransfer(mission)
 	}
 
-	time.Sleep((mission.TravelTime - timeSlept) * time.Millisecond)
-	target, stateChange, err = fetchMissionTarget(targetKey)
+	if !sleepUnlessRecalled(c, (mission.TravelTime-timeSlept)*time.Millisecond, recall) {
+		return
+	}
+	target, stateChange, err = u.fetchMissionTarget(targetKey)
 	if err != nil {
+		// The target is gone along with the season
 		log.Print("fetchMissionTarget fail: ", err.Error())
+		world.RemoveFromArea(mission.Key(), mission.AreaSet())
+		world.Delete(mission.Key())
 		return
 	}
 	ownerBeforeMission := target.Owner
@@ -92,7 +127,7 @@ func StartMissionary(mission *entities.Mission) {
 	if ownerBeforeMission == "" {
 		player = nil
 	} else {
-		playerEntity, pErr := entities.Get(fmt.Sprintf("player.%s", ownerBeforeMission))
+		playerEntity, pErr := world.Get(fmt.Sprintf("player.%s", ownerBeforeMission))
 		if pErr != nil {
 			log.Println("Error in target planet owner fetch:", pErr.Error())
 			return
@@ -100,107 +135,209 @@ func StartMissionary(mission *entities.Mission) {
 		player = playerEntity.(*entities.Player)
 	}
 
-	switch mission.Type {
-	case "Attack":
+	switch {
+	case attacksOnArrival(mission, target) && (target.OnVacation || target.IsProtected()):
+		// The owner has gone on vacation or the planet has been taken by
+		// a newbie in the meantime, so the ships go back without a fight
+		excessShips = mission.ShipCount
+	case mission.Type == "Attack":
 		if err != nil {
 			log.Print("Error in target planet fetch:", err.Error())
 		}
+		defenders := target.ShipCount
 		excessShips, ownerHasChanged = mission.EndAttackMission(target)
-		clients.Broadcast(target)
-	case "Supply":
+		u.Clients().Broadcast(target)
+		if mission.Type == "Attack" {
+			u.recordBattle(mission, target, ownerBeforeMission, defenders)
+		}
+	case mission.Type == "Supply":
 		if err != nil {
 			log.Print("Error in target planet fetch:", err.Error())
 		}
 		excessShips, ownerHasChanged = mission.EndSupplyMission(target)
 		if player != nil {
-			clients.Send(player, stateChange)
+			u.Clients().Send(player, stateChange)
 		}
-	case "Spy":
-		for {
-			if err != nil {
-				log.Print("Error in target planet fetch:", err.Error())
-			}
-			// All spy pilots die if planet is overtaken (they are killed)
-			// Other possible solution is to generate a supply mission back (they flee)
-			if target.Owner != mission.Target.Owner {
-				break
-			}
-			mission.EndSpyMission(target)
-			updateSpyReports(mission, stateChange)
-			if mission.ShipCount > 0 {
-				time.Sleep(entities.Settings.SpyReportValidity * time.Second)
-			} else {
-				break
-			}
-			target, stateChange, err = fetchMissionTarget(targetKey)
-		}
-		time.Sleep(entities.Settings.SpyReportValidity * time.Second)
-		updateSpyReports(mission, stateChange)
+	case mission.Type == "Spy":
+		target, excessShips = u.stationSpies(c, mission, target, targetKey, recall)
 	}
 
-	entities.RemoveFromArea(mission.Key(), mission.AreaSet())
-	entities.Delete(mission.Key())
-	entities.Save(target)
+	world.RemoveFromArea(mission.Key(), mission.AreaSet())
+	world.Delete(mission.Key())
+	if ownerHasChanged {
+		u.takeOver(target)
+	}
+	if mission.Type != "Spy" {
+		// Spies leave the planet as it is, while the copy they have seen
+		// last could be long outdated
+		world.Save(target)
+	}
 
 	if ownerHasChanged {
-		go func(owned, owner string) {
-			leaderBoard.Channel <- [2]string{owned, owner}
-		}(ownerBeforeMission, target.Owner)
+		u.recordOwnerChange(target, ownerBeforeMission)
+		eliminated := player != nil && player.HomePlanet == target.Key()
+		go func(board *leaderboard.Leaderboard, transfer leaderboard.PlanetTransfer) {
+			board.Channel <- transfer
+		}(u.Leaderboard(), leaderboard.PlanetTransfer{
+			From:            ownerBeforeMission,
+			To:              target.Owner,
+			EnergyPerMinute: world.PlanetProduction(target.Size, target.IsHome).EnergyPerMinute,
+			Eliminated:      eliminated,
+		})
 
 		if player != nil {
 			ownerChange := response.NewOwnerChange()
 			ownerChange.RawPlanet = map[string]*entities.Planet{
 				target.Key(): target,
 			}
-			clients.Send(player, ownerChange)
+			u.Clients().Send(player, ownerChange)
+		}
+		if eliminated {
+			u.eliminate(player, target)
+		}
+		if target.IsHome {
+			u.homePlanetCaptured()
 		}
 	}
 
 	if excessShips > 0 {
-		startExcessMission(mission, target, excessShips)
+		u.startExcessMission(mission, target, excessShips)
+	}
+}
+
+// Tells whether the mission ends up attacking a planet of someone else.
+// Supplies turn into attacks when the planet has changed hands on the way.
+func attacksOnArrival(mission *entities.Mission, target *entities.Planet) bool {
+	if !target.HasOwner() || target.Owner == mission.Player {
+		return false
+	}
+	switch mission.Type {
+	case "Attack":
+		return true
+	case "Supply":
+		return target.Owner != mission.Target.Owner
 	}
+	return false
 }
 
-func startExcessMission(mission *entities.Mission, homePlanet *entities.Planet, ships int32) {
+func (u *Universe) startExcessMission(mission *entities.Mission, homePlanet *entities.Planet, ships int32) {
 	newTargetKey := fmt.Sprintf("planet.%s", mission.Source.Name)
-	newTargetEntity, err := entities.Get(newTargetKey)
+	newTargetEntity, err := u.World().Get(newTargetKey)
 	if err != nil {
 		log.Print("Error in newTarget planet fetch: ", err.Error())
 		return
 	}
 
-	playerEntity, err := entities.Get(fmt.Sprintf("player.%s", mission.Player))
+	playerEntity, err := u.World().Get(fmt.Sprintf("player.%s", mission.Player))
 	player := playerEntity.(*entities.Player)
 
-	excessMission := player.StartMission(homePlanet, newTargetEntity.(*entities.Planet), []*vec2d.Vector{}, 100, "Attack")
-	excessMission.ShipCount = ships
-	go StartMissionary(excessMission)
-	entities.Save(excessMission)
-	clients.Broadcast(excessMission)
+	// The ships only go back home, so they supply it instead of attacking
+	// it, unless it has changed hands in the meantime
+	excessMission := player.StartMission(homePlanet, newTargetEntity.(*entities.Planet), []*vec2d.Vector{}, 100, "Supply")
+	excessMission.Target.Owner = mission.Player
+	excessMission.SetShipCount(ships)
+	u.spawnMissionary(excessMission)
+	u.World().Save(excessMission)
+	u.Clients().Broadcast(excessMission)
+	u.recordMission(excessMission)
 }
 
-func updateSpyReports(mission *entities.Mission, state *response.StateChange) {
-	var (
-		player *entities.Player
-		err    error
-	)
+// Keeps the spies above the target, reporting every mission.ReportInterval
+// until they run out of reports, get recalled or the planet is overtaken.
+// Returns the target as it was last seen and the ships which survived,
+// so they could go back home. None survive if the missionary is called
+// back at the end of the season.
+func (u *Universe) stationSpies(c clock.Clock, mission *entities.Mission, target *entities.Planet, targetKey string, seasonEnd <-chan struct{}) (*entities.Planet, int32) {
+	if mission.ReportInterval == 0 {
+		// Spies sent before the stations existed
+		mission.Station(0, 0)
+	}
+	recall := spyStations.add(mission.Key())
+	defer spyStations.remove(mission.Key())
+
+	for mission.ReportsLeft > 0 {
+		// All spy pilots die if planet is overtaken (they are killed)
+		// Other possible solution is to generate a supply mission back (they flee)
+		// While the owner stays the same, the spies could be detected on every report
+		if target.Owner != mission.Target.Owner {
+			return target, 0
+		}
 
+		if killed := mission.CounterIntelligence(target); killed > 0 {
+			u.spiesDetected(mission, killed, target)
+			if mission.ShipCount == 0 {
+				return target, 0
+			}
+		}
+
+		report := mission.EndSpyMission(target)
+		if report == nil {
+			return target, 0
+		}
+		u.World().Save(mission)
+		u.Clients().Broadcast(mission)
+		u.record(replay.SpyReport, mission.Player, report)
+		u.sendSpyReport(mission, report, target)
+
+		if mission.ReportsLeft == 0 {
+			break
+		}
+
+		timer := c.NewTimer(mission.ReportInterval * time.Second)
+		select {
+		case <-recall:
+			timer.Stop()
+			return target, mission.ShipCount
+		case <-seasonEnd:
+			timer.Stop()
+			return target, 0
+		case <-timer.C:
+		}
+
+		newTarget, _, err := u.fetchMissionTarget(targetKey)
+		if err != nil {
+			log.Print("Error in target planet fetch:", err.Error())
+			return target, 0
+		}
+		target = newTarget
+	}
+	return target, mission.ShipCount
+}
+
+// Writes the detection in the history of both players and lets them know
+// if they are online. Everyone else just sees the mission shrink.
+func (u *Universe) spiesDetected(mission *entities.Mission, killed int32, target *entities.Planet) {
+	u.World().RecordHistory(target.Owner, entities.SpiesDetected, target.Name, mission.Player, killed)
+	u.World().RecordHistory(mission.Player, entities.SpiesCaught, target.Name, target.Owner, killed)
+
+	u.World().Save(mission)
+	u.Clients().Broadcast(mission)
+
+	for _, username := range []string{target.Owner, mission.Player} {
+		if player, err := u.Clients().Player(username); err == nil {
+			u.Clients().Send(player, response.NewSpiesDetected(mission, killed, target))
+		}
+	}
+}
+
+// Pushes the report to the player who sent the spies, if he is online.
+func (u *Universe) sendSpyReport(mission *entities.Mission, report *entities.SpyReport, target *entities.Planet) {
 	if mission.Player == "" {
 		log.Print("Error! Found mission with empty owner.")
 		return
 	}
 
-	player, err = clients.Player(mission.Player)
+	player, err := u.Clients().Player(mission.Player)
 	if err != nil {
 		return
 	}
 
-	clients.UpdateSpyReports(player)
-	clients.Send(player, state)
+	u.Clients().UpdateSpyReports(player)
+	u.Clients().Send(player, response.NewSpyReport(mission, report, target))
 }
 
-func fetchMissionTarget(targetKey string) (*entities.Planet, *response.StateChange, error) {
-	targetEntity, err := entities.Get(targetKey)
+func (u *Universe) fetchMissionTarget(targetKey string) (*entities.Planet, *response.StateChange, error) {
+	targetEntity, err := u.World().Get(targetKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -211,7 +348,30 @@ func fetchMissionTarget(targetKey string) (*entities.Planet, *response.StateChan
 	stateChange.RawPlanets = map[string]*entities.Planet{
 		target.Key(): target,
 	}
-	clients.Broadcast(target)
+	u.Clients().Broadcast(target)
 
 	return target, stateChange, nil
 }
+
+// Passes the newbie protection and the vacation of the new owner to the
+// conquered planet. The protection ends if they own enough planets
+// already.
+func (u *Universe) takeOver(target *entities.Planet) {
+	entity, err := u.World().Get(fmt.Sprintf("player.%s", target.Owner))
+	if err != nil {
+		log.Print("Error in new owner fetch: ", err.Error())
+		return
+	}
+	owner := entity.(*entities.Player)
+	protected := owner.IsProtected()
+	target.OnVacation = owner.OnVacation()
+
+	for _, planet := range u.World().ProtectConquest(owner, target) {
+		u.Clients().Broadcast(planet)
+	}
+	if protected && !owner.IsProtected() {
+		if online, err := u.Clients().Player(owner.Username); err == nil {
+			online.ProtectedUntil = 0
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"warcluster/clock"
+)
+
+// Keeps track of the missionaries of a universe, so all of them could be
+// called back and waited for before the season ends. Otherwise they would
+// save what they have fetched during the old season in the new one.
+type missionaryPool struct {
+	mutex     sync.Mutex
+	running   sync.WaitGroup
+	recall    chan struct{}
+	recalling bool
+}
+
+// The missionaries of the default universe.
+var missionaries = new(missionaryPool)
+
+// Registers a new missionary and returns the channel closed when it is
+// called back. Returns false if the missionaries are being called back,
+// so no new ones could start.
+func (p *missionaryPool) add() (<-chan struct{}, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.recalling {
+		return nil, false
+	}
+	if p.recall == nil {
+		p.recall = make(chan struct{})
+	}
+	p.running.Add(1)
+	return p.recall, true
+}
+
+// Marks a missionary registered with add as finished.
+func (p *missionaryPool) done() {
+	p.running.Done()
+}
+
+// Calls back all missionaries and waits for them to finish. No new ones
+// start until resume is called.
+func (p *missionaryPool) recallAll() {
+	p.mutex.Lock()
+	p.recalling = true
+	if p.recall != nil {
+		close(p.recall)
+		p.recall = nil
+	}
+	p.mutex.Unlock()
+
+	p.running.Wait()
+}
+
+// Lets new missionaries start again after recallAll.
+func (p *missionaryPool) resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.recalling = false
+}
+
+// Pauses the missionary for d on the given clock. Returns false if it is
+// called back in the meantime.
+func sleepUnlessRecalled(c clock.Clock, d time.Duration, recall <-chan struct{}) bool {
+	timer := c.NewTimer(d)
+	select {
+	case <-recall:
+		timer.Stop()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
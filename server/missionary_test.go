@@ -0,0 +1,82 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/entities"
+)
+
+func TestMissionAreaTransfers(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.May, 20, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	u := newTestUniverse(t, *entities.Settings())
+	world := u.World()
+	pilot := registerTestPlayer(u, "pilot")
+
+	areaSize := float64(world.Settings().AreaSize)
+	source := &entities.Planet{
+		Name:                "SRC1",
+		Position:            vec2d.New(areaSize*100+areaSize/2, areaSize/2),
+		LastShipCountUpdate: fake.Now().Unix(),
+		ShipCount:           100,
+		Owner:               pilot.Username,
+	}
+	target := &entities.Planet{
+		Name:                "DST1",
+		Position:            vec2d.New(areaSize*102+areaSize/2, -areaSize*3/2),
+		LastShipCountUpdate: fake.Now().Unix(),
+		Owner:               pilot.Username,
+	}
+	world.Save(source)
+	world.Save(target)
+
+	mission, err := prepareMission(source.Key(), target, &Request{
+		Client:       testClient(u, pilot),
+		StartPlanets: []string{source.Key()},
+		EndPlanet:    target.Key(),
+		Type:         "Supply",
+		Fleet:        100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"area:101:1", "area:102:1", "area:102:-1", "area:103:-1", "area:103:-2"}
+	if areas := mission.Areas(); !reflect.DeepEqual(areas, expected) {
+		t.Fatalf("The mission is going through %v instead of %v", areas, expected)
+	}
+
+	var visited []string
+	for {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := world.Get(mission.Key()); err != nil {
+			break
+		}
+
+		var current []string
+		for _, area := range expected {
+			if world.InArea(mission.Key(), area) {
+				current = append(current, area)
+			}
+		}
+		if len(current) != 1 {
+			t.Fatalf("The mission is in %v at once", current)
+		}
+		if len(visited) == 0 || visited[len(visited)-1] != current[0] {
+			visited = append(visited, current[0])
+		}
+		fake.Step()
+	}
+
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("The mission has flown through %v instead of %v", visited, expected)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/leaderboard"
+)
+
+// StartPirates creates the pirate factions from the settings and lets them
+// raid the players around them every Settings().PirateRaidInterval seconds.
+// Settings are read on every raid, so factions could be added on reload.
+// Factions dropped from the settings keep their planets, but stop raiding.
+// The pirates raid in a goroutine of the current clock.
+func StartPirates() {
+	clock.Go(defaultUniverse.startPirates)
+}
+
+// Lets the pirates of the universe raid on the given clock, just like
+// StartPirates does.
+func (u *Universe) startPirates(c clock.Clock) {
+	for {
+		pirates := u.spawnPirates()
+		if len(pirates) > 0 {
+			u.raid(pirates)
+		}
+		clock.SleepOn(c, u.World().Settings().PirateRaidInterval*time.Second)
+	}
+}
+
+// Creates the factions which don't exist yet and returns all of them.
+func (u *Universe) spawnPirates() []*entities.Player {
+	var pirates []*entities.Player
+
+	for n := 1; n <= u.World().Settings().PirateFactions; n++ {
+		name := entities.PirateName(n)
+		entity, _ := u.World().Get(fmt.Sprintf("player.%s", name))
+		if entity == nil {
+			pirates = append(pirates, u.registerPirates(name, uint8((n-1)%len(entities.Races))))
+		} else if player := entity.(*entities.Player); player.NPC {
+			pirates = append(pirates, player)
+		} else {
+			log.Printf("Player %s took the name of a pirate faction", name)
+		}
+	}
+	return pirates
+}
+
+// Saves a new pirate faction, just like a newly registered player.
+func (u *Universe) registerPirates(name string, race uint8) *entities.Player {
+	world := u.World()
+	player, sun, planets := world.CreatePirates(name, race)
+	leaderboardPlayer := &leaderboard.Player{
+		Username:   player.Username,
+		RaceId:     player.RaceID,
+		HomePlanet: strings.TrimPrefix(player.HomePlanet, "planet."),
+	}
+
+	for _, planet := range planets {
+		world.Save(planet)
+		u.Clients().Broadcast(planet)
+		leaderboardPlayer.Planets++
+		leaderboardPlayer.EnergyPerMinute += world.PlanetProduction(planet.Size, planet.IsHome).EnergyPerMinute
+	}
+
+	world.Save(player)
+	world.Save(sun)
+
+	u.Clients().Broadcast(sun)
+	u.Leaderboard().Add(leaderboardPlayer)
+	log.Printf("Pirates %s have settled in %s", name, sun.Name)
+	return player
+}
+
+// Sends one attack of every faction from its biggest fleet to the nearest
+// player's planet in range.
+func (u *Universe) raid(pirates []*entities.Player) {
+	npcs := make(map[string]bool, len(pirates))
+	for _, pirate := range pirates {
+		npcs[pirate.Username] = true
+	}
+
+	var planets []*entities.Planet
+	for _, entity := range u.World().Find("planet.*") {
+		planets = append(planets, entity.(*entities.Planet))
+	}
+
+	for _, pirate := range pirates {
+		mission, err := u.raidFrom(pirate, planets, npcs)
+		if err != nil {
+			log.Printf("Pirates %s could not raid: %s", pirate.Username, err)
+		} else if mission != nil {
+			log.Printf("Pirates %s raid %s", pirate.Username, mission.Target.Name)
+		}
+	}
+}
+
+// Starts the raid of a single faction through the very same checks as
+// the missions of the players. Returns nil if there is nothing to raid.
+func (u *Universe) raidFrom(pirate *entities.Player, planets []*entities.Planet, npcs map[string]bool) (*entities.Mission, error) {
+	var source *entities.Planet
+	for _, planet := range planets {
+		if planet.Owner == pirate.Username && (source == nil || planet.GetShipCount() > source.ShipCount) {
+			source = planet
+		}
+	}
+	settings := u.World().Settings()
+	if source == nil || source.ShipCount < settings.PirateRaidMinShips {
+		return nil, nil
+	}
+
+	target := entities.RaidTarget(source, planets, npcs)
+	if target == nil {
+		return nil, nil
+	}
+
+	client := NewClient(nil, pirate, nil)
+	client.universe = u
+	request := &Request{
+		Client:       client,
+		Command:      "start_mission",
+		Type:         "Attack",
+		StartPlanets: []string{source.Key()},
+		EndPlanet:    target.Key(),
+		Fleet:        settings.PirateRaidFleet,
+	}
+	return prepareMission(source.Key(), target, request)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/entities"
+)
+
+func TestRaidFrom(t *testing.T) {
+	pirates := &entities.Player{
+		Username:   entities.PirateName(1),
+		HomePlanet: "planet.PIR1230",
+		NPC:        true,
+	}
+	source := &entities.Planet{
+		Name:                "PIR1230",
+		Position:            vec2d.New(0, 0),
+		Owner:               pirates.Username,
+		ShipCount:           entities.Settings().PirateRaidMinShips * 2,
+		MaxShipCount:        1000,
+		LastShipCountUpdate: time.Now().Unix(),
+	}
+	target := &entities.Planet{
+		Name:     "GOP6725",
+		Position: vec2d.New(100, 100),
+		Owner:    "gophie",
+	}
+	entities.Save(source)
+	entities.Save(target)
+	entities.Save(pirates)
+	planets := []*entities.Planet{source, target}
+	npcs := map[string]bool{pirates.Username: true}
+
+	mission, err := defaultUniverse.raidFrom(pirates, planets, npcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mission == nil || mission.Target.Name != target.Name || mission.Type != "Attack" {
+		t.Fatalf("Pirates started %#v instead of raiding %s", mission, target.Name)
+	}
+	if mission.Player != pirates.Username {
+		t.Errorf("The raid is started by %s", mission.Player)
+	}
+
+	source.ShipCount = 1
+	if mission, _ := defaultUniverse.raidFrom(pirates, planets, npcs); mission != nil {
+		t.Errorf("Pirates raid %s with too few ships", mission.Target.Name)
+	}
+}
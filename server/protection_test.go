@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/server/response"
+)
+
+func TestNewbieProtection(t *testing.T) {
+	settings := *entities.Settings()
+	settings.NewbieProtectionPlanets = 2
+	u := newTestUniverse(t, settings)
+	world := u.World()
+
+	newbie := registerTestPlayer(u, "newbie")
+	veteran := registerTestPlayer(u, "veteran")
+	if !newbie.IsProtected() {
+		t.Fatal("The new player is not protected")
+	}
+	world.EndProtection(veteran)
+	entity, _ := world.Get(newbie.HomePlanet)
+	if packet := entity.(*entities.Planet).Sanitize(veteran); !packet.IsProtected {
+		t.Error("The home planet of the newbie is not shown as protected")
+	}
+
+	attack := func(attacker, target *entities.Player) error {
+		entity, _ := world.Get(target.HomePlanet)
+		_, err := prepareMission(attacker.HomePlanet, entity.(*entities.Planet), &Request{
+			Client:       testClient(u, attacker),
+			StartPlanets: []string{attacker.HomePlanet},
+			EndPlanet:    target.HomePlanet,
+			Type:         "Attack",
+			Fleet:        10,
+		})
+		return err
+	}
+
+	if err := attack(veteran, newbie); err == nil || err.Error() != "The planet is under newbie protection." {
+		t.Errorf("The newbie is attacked: %v", err)
+	}
+	if err := attack(newbie, veteran); err != nil {
+		t.Fatal(err)
+	}
+	entity, _ = world.Get(newbie.HomePlanet)
+	if home := entity.(*entities.Planet); newbie.IsProtected() || home.IsProtected() {
+		t.Error("The newbie is still protected after attacking")
+	}
+	if err := attack(veteran, newbie); err != nil {
+		t.Errorf("The newbie is protected after attacking: %s", err)
+	}
+
+	settler := registerTestPlayer(u, "settler")
+	var neutral *entities.Planet
+	for _, entity := range world.Find("planet.*") {
+		if planet := entity.(*entities.Planet); !planet.HasOwner() {
+			neutral = planet
+		}
+	}
+	neutral.Owner = settler.Username
+	world.ProtectConquest(settler, neutral)
+	if settler.IsProtected() || neutral.IsProtected() {
+		t.Errorf("The settler is protected with %d planets", settings.NewbieProtectionPlanets)
+	}
+}
+
+func TestPreviewOfProtectedPlanets(t *testing.T) {
+	u := newTestUniverse(t, *entities.Settings())
+	newbie := registerTestPlayer(u, "newbie")
+	veteran := registerTestPlayer(u, "veteran")
+	u.World().EndProtection(veteran)
+
+	client := testClient(u, veteran)
+	err := previewMission(&Request{
+		Client:       client,
+		StartPlanets: []string{veteran.HomePlanet},
+		EndPlanet:    newbie.HomePlanet,
+		Type:         "Attack",
+		Fleet:        10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var previews response.MissionPreviews
+	json.Unmarshal(client.codec.(*fakeCodec).Messages[0], &previews)
+	if failure := previews.FailedMissions[veteran.HomePlanet]; failure != "The planet is under newbie protection." {
+		t.Errorf("The attack of a newbie is previewed with %q", failure)
+	}
+}
+
+func TestAttackOfPlanetTakenByNewbie(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 6, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	u := newTestUniverse(t, *entities.Settings())
+	world := u.World()
+	newbie := registerTestPlayer(u, "newbie")
+	veteran := registerTestPlayer(u, "veteran")
+	world.EndProtection(veteran)
+
+	var neutral *entities.Planet
+	for _, entity := range world.Find("planet.*") {
+		if planet := entity.(*entities.Planet); !planet.HasOwner() {
+			neutral = planet
+		}
+	}
+	attack, err := prepareMission(veteran.HomePlanet, neutral, &Request{
+		Client:       testClient(u, veteran),
+		StartPlanets: []string{veteran.HomePlanet},
+		EndPlanet:    neutral.Key(),
+		Type:         "Attack",
+		Fleet:        100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The newbie settles the planet while the attack is on its way
+	neutral.Owner = newbie.Username
+	world.ProtectConquest(newbie, neutral)
+	world.Save(neutral)
+	entity, _ := world.Get(neutral.Key())
+	ships := entity.(*entities.Planet).ShipCount
+
+	for {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := world.Get(attack.Key()); err != nil {
+			break
+		}
+		fake.Step()
+	}
+	entity, _ = world.Get(neutral.Key())
+	if planet := entity.(*entities.Planet); planet.ShipCount < ships || planet.Owner != newbie.Username {
+		t.Errorf("The attack has left %d of %d ships to %s", planet.ShipCount, ships, planet.Owner)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"log"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/entities"
+	"warcluster/replay"
+	"warcluster/server/response"
+)
+
+// Log of the game in the default universe. Nothing is recorded while it
+// is nil.
+var recorder *replay.Recorder
+
+// Guards the recorders of all universes, which are started and stopped
+// while the game is recorded from many goroutines.
+var recorders sync.RWMutex
+
+// Starts appending everything that happens in the default universe to the
+// log at the given path.
+func StartRecording(path string) error {
+	return defaultUniverse.StartRecording(path)
+}
+
+// Stops the recordings of all universes.
+func StopRecording() error {
+	for _, u := range startedUniverses() {
+		u.StopRecording()
+	}
+	return defaultUniverse.StopRecording()
+}
+
+// Returns where the recorder of the universe is kept. It has to be
+// accessed only while holding recorders.
+func (u *Universe) recorderRef() **replay.Recorder {
+	if u == nil {
+		return &recorder
+	}
+	return &u.recorder
+}
+
+// Starts appending everything that happens in the universe to the log at
+// the given path.
+func (u *Universe) StartRecording(path string) error {
+	r, err := replay.Open(path)
+	if err != nil {
+		return err
+	}
+	recorders.Lock()
+	*u.recorderRef() = r
+	recorders.Unlock()
+	log.Printf("Recording the game in %s", path)
+	return nil
+}
+
+// Stops the recording started with StartRecording.
+func (u *Universe) StopRecording() error {
+	recorders.Lock()
+	ref := u.recorderRef()
+	r := *ref
+	*ref = nil
+	recorders.Unlock()
+
+	if r == nil {
+		return nil
+	}
+	return r.Close()
+}
+
+// Returns the recorder of the universe, nil if the game isn't recorded.
+func (u *Universe) currentRecorder() *replay.Recorder {
+	recorders.RLock()
+	defer recorders.RUnlock()
+	return *u.recorderRef()
+}
+
+// Appends an event to the log, if the game is recorded.
+func (u *Universe) record(eventType, player string, data interface{}) {
+	r := u.currentRecorder()
+	if r == nil {
+		return
+	}
+	if err := r.Record(eventType, player, data); err != nil {
+		log.Printf("Error recording %s: %s", eventType, err)
+	}
+}
+
+// Records a command accepted from a player. The twitter tokens are not
+// written down.
+func recordCommand(request *Request) {
+	u := request.Client.universe
+	if u.currentRecorder() == nil {
+		return
+	}
+	recorded := *request
+	recorded.AccessToken = ""
+	recorded.AccessTokenSecret = ""
+	u.record(replay.Command, request.Client.Player.Username, &recorded)
+}
+
+func (u *Universe) recordMission(mission *entities.Mission) {
+	u.record(replay.MissionLaunched, mission.Player, mission)
+}
+
+func (u *Universe) recordAreaTransfer(mission *entities.Mission) {
+	u.record(replay.AreaTransfer, mission.Player, &replay.AreaTransferData{
+		Mission: mission.Key(),
+		Area:    mission.AreaSet(),
+	})
+}
+
+func (u *Universe) recordBattle(mission *entities.Mission, target *entities.Planet, defender string, defenders int32) {
+	u.record(replay.Battle, mission.Player, &replay.BattleData{
+		Mission:   mission.Key(),
+		Planet:    target.Name,
+		Attacker:  mission.Player,
+		Defender:  defender,
+		Attackers: mission.ShipCount,
+		Defenders: defenders,
+		ShipsLeft: target.ShipCount,
+	})
+}
+
+func (u *Universe) recordOwnerChange(target *entities.Planet, from string) {
+	u.record(replay.OwnerChange, target.Owner, &replay.OwnerChangeData{
+		Planet: target.Name,
+		From:   from,
+		To:     target.Owner,
+	})
+}
+
+// Returns a handler streaming the recorded game to every spectator, who
+// connects to it, from the very beginning. Spectators choose how fast
+// the game is played with ?speed=<n>, it is played with defaultSpeed
+// otherwise.
+func ReplayHandler(events []*replay.Event, defaultSpeed float64) websocket.Handler {
+	return func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		speed := defaultSpeed
+		if value := ws.Request().URL.Query().Get("speed"); value != "" {
+			var err error
+			if speed, err = strconv.ParseFloat(value, 64); err != nil || speed <= 0 {
+				websocket.JSON.Send(ws, response.NewError("Invalid speed"))
+				return
+			}
+		}
+
+		err := replay.Play(events, speed, func(event *replay.Event) error {
+			return websocket.JSON.Send(ws, response.NewReplayEvent(event))
+		})
+		if err != nil {
+			log.Println("Replay stopped:", err)
+			return
+		}
+		websocket.JSON.Send(ws, response.NewReplayEnd(len(events)))
+	}
+}
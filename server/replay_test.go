@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/replay"
+)
+
+func TestRecordGame(t *testing.T) {
+	defer setSpyDetection(0)()
+
+	var log bytes.Buffer
+	recorder = replay.NewRecorder(&log)
+	defer StopRecording()
+
+	s := NewSimulation(time.Date(2014, time.March, 1, 12, 0, 0, 0, time.UTC))
+	defer s.Close()
+
+	alice, err := s.Register("alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := nearestNeutralPlanet(t, s, alice)
+	err = s.Send("alice", &Request{
+		Command:      "start_mission",
+		StartPlanets: []string{alice.HomePlanet},
+		EndPlanet:    target,
+		Type:         "Attack",
+		Fleet:        80,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Advance(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := replay.Read(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+		if event.Player != "alice" {
+			t.Errorf("%s is recorded for %q", event.Type, event.Player)
+		}
+	}
+
+	// Commands are recorded once they succeed, so right after their outcome.
+	expected := []string{replay.MissionLaunched, replay.Command, replay.Battle, replay.OwnerChange}
+	if len(types) < len(expected) || types[0] != expected[0] || types[1] != expected[1] ||
+		types[len(types)-2] != expected[2] || types[len(types)-1] != expected[3] {
+		t.Errorf("Recorded %v instead of %v with area transfers in the middle", types, expected)
+	}
+	for _, eventType := range types[2 : len(types)-2] {
+		if eventType != replay.AreaTransfer {
+			t.Errorf("Unexpected %s in the middle of the mission", eventType)
+		}
+	}
+	if strings.Contains(string(events[1].Data), "Client") {
+		t.Errorf("The client is recorded with the command: %s", events[1].Data)
+	}
+}
+
+func TestReplayHandler(t *testing.T) {
+	events := []*replay.Event{
+		{Time: 1000, Type: replay.Command, Player: "gophie", Data: []byte(`{}`)},
+		{Time: 1500, Type: replay.MissionLaunched, Player: "gophie", Data: []byte(`{}`)},
+	}
+	httpServer := httptest.NewServer(ReplayHandler(events, 1))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	ws, err := websocket.Dial(url+"?speed=100", "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var message struct {
+		Command string
+		Event   *replay.Event
+		Events  int
+	}
+	for _, event := range events {
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Command != "replay_event" || message.Event == nil || message.Event.Type != event.Type {
+			t.Errorf("Received %#v instead of %s", message, event.Type)
+		}
+	}
+	message.Event = nil
+	if err := websocket.JSON.Receive(ws, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Command != "replay_end" || message.Events != len(events) {
+		t.Errorf("Received %#v instead of the end of the replay", message)
+	}
+
+	ws, err = websocket.Dial(url+"?speed=-1", "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if err := websocket.JSON.Receive(ws, &message); err != nil || message.Command != "error" {
+		t.Errorf("Negative speed is not rejected: %#v", message)
+	}
+}
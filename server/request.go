@@ -8,7 +8,7 @@ import (
 
 // Request type hold player's requests data
 type Request struct {
-	Client            *Client         // Reference to the client who sent this. Populated by the server.
+	Client            *Client         `json:"-"` // Reference to the client who sent this. Populated by the server.
 	Command           string          // Used to parse the request (required)
 	Type              string          // Type of mission (possible values are: Attach, Supply, Spy)
 	Position          *vec2d.Vector   // Position of the client when he sent the request
@@ -17,12 +17,18 @@ type Request struct {
 	Path              []*vec2d.Vector // All intermidiate points (waypoints) that define the missions path
 	EndPlanet         string          // Mission's destination
 	Fleet             int32           // Percentge of ships to be sent in the start mission request
+	Boost             bool            // Spend energy to make the missions faster
+	ReportInterval    int64           // Seconds between two reports of a spy station
+	SpyDuration       int64           // Seconds the spies stay above the target (0 means until they run out)
+	Mission           string          // Mission whose spies have to be recalled
 	Username          string          // Client's username needed while loggin in
 	TwitterID         string          // Client's twitter id needed while logging in
 	Race              uint8           // Race ID chosen during registration
 	SunTextureId      uint16          // Sun Texture ID chosen during registration
 	AccessToken       string          // Twitter consumer secret
 	AccessTokenSecret string          // Twitter consumer secret
+	Planet            string          // Planet to be upgraded
+	Building          string          // Building to be upgraded (shipyard, defences, radar or storage)
 }
 
 // ParseRequest is serving the purpouse of a request manager. Determines the
@@ -38,12 +44,41 @@ func ParseRequest(request *Request) (func(*Request) error, error) {
 		} else {
 			return nil, errors.New("Not enough arguments")
 		}
+	case "end_vacation":
+		return endVacation, nil
+	case "history":
+		return history, nil
+	case "preview_mission":
+		if len(request.StartPlanets) > 0 && len(request.EndPlanet) > 0 {
+			if request.Fleet > 100 || request.Fleet <= 0 {
+				request.Fleet = 100
+			}
+			return previewMission, nil
+		} else {
+			return nil, errors.New("Not enough arguments")
+		}
+	case "recall_spies":
+		if len(request.Mission) > 0 {
+			return recallSpies, nil
+		} else {
+			return nil, errors.New("Not enough arguments")
+		}
+	case "respawn":
+		return respawn, nil
+	case "start_vacation":
+		return startVacation, nil
 	case "scope_of_view":
 		if request.Position != nil && len(request.Resolution) > 0 {
 			return scopeOfView, nil
 		} else {
 			return nil, errors.New("Not enough arguments")
 		}
+	case "upgrade_planet":
+		if len(request.Planet) > 0 && len(request.Building) > 0 {
+			return upgradePlanet, nil
+		} else {
+			return nil, errors.New("Not enough arguments")
+		}
 	case "voronoi_diagram":
 		if request.Position != nil && len(request.Resolution) > 0 {
 			return voronoiDiagram, nil
@@ -14,7 +14,14 @@ func TestAllTypesOfMission(t *testing.T) {
 		output func(*Request) error
 	}{
 		{"start_mission", parseAction},
+		{"preview_mission", previewMission},
 		{"scope_of_view", scopeOfView},
+		{"upgrade_planet", upgradePlanet},
+		{"recall_spies", recallSpies},
+		{"history", history},
+		{"start_vacation", startVacation},
+		{"end_vacation", endVacation},
+		{"respawn", respawn},
 		{"something_else", nil},
 	}
 
@@ -23,6 +30,9 @@ func TestAllTypesOfMission(t *testing.T) {
 	request.EndPlanet = "end"
 	request.Position = vec2d.New(2.0, 4.0)
 	request.Resolution = []uint64{1920, 1080}
+	request.Planet = "planet"
+	request.Building = "radar"
+	request.Mission = "mission"
 
 	for _, test := range tableTests {
 		request.Command = test.input
@@ -2,7 +2,9 @@ package server
 
 import (
 	"errors"
+	"time"
 
+	"warcluster/clock"
 	"warcluster/entities"
 	"warcluster/server/response"
 )
@@ -17,18 +19,29 @@ var missionTypes = map[string]struct{}{
 // to call calculateCanvasSize and give the player the information
 // contained in the given borders.
 func scopeOfView(request *Request) error {
-	response := response.NewScopeOfView(request.Position, request.Resolution)
+	u := request.Client.universe
+	response := response.NewScopeOfView(u.World(), request.Position, radarResolution(request))
 	request.Client.Player.ScreenPosition = request.Position
-	go entities.Save(request.Client.Player)
-	clients.Send(request.Client.Player, response)
+	go u.World().Save(request.Client.Player)
+	u.Clients().Send(request.Client.Player, response)
 	request.Client.MoveToAreas(response.Areas())
 
 	return nil
 }
 
+// Widens the client's resolution with the radar on the player's home planet.
+func radarResolution(request *Request) []uint64 {
+	radar := request.Client.Player.RadarModifier()
+	resolution := make([]uint64, len(request.Resolution))
+	for i, size := range request.Resolution {
+		resolution[i] = uint64(float64(size) * radar)
+	}
+	return resolution
+}
+
 func voronoiDiagram(request *Request) error {
     response := response.NewVoronoiDiagram(request.Position, request.Resolution)
-    clients.Send(request.Client.Player, response)
+    request.Client.universe.Clients().Send(request.Client.Player, response)
 
     return nil
 }
@@ -46,24 +59,10 @@ func parseAction(request *Request) error {
 		return err
 	}()
 
-	if len(request.StartPlanets) == 0 {
-		errorMessage := "No start planets provided"
-		sendMissionMessage.FailedMissions["Global"] = errorMessage
-		return errors.New(errorMessage)
-	}
-
-	target, err := entities.Get(request.EndPlanet)
+	endPlanet, err := missionTarget(request)
 	if err != nil {
-		errorMessage := "End planet does not exist"
-		sendMissionMessage.FailedMissions["Global"] = errorMessage
-		return errors.New(errorMessage)
-	}
-	endPlanet := target.(*entities.Planet)
-
-	if _, isMissionTypeValid := missionTypes[request.Type]; !isMissionTypeValid {
-		errorMessage := "Invalid mission type!"
-		sendMissionMessage.FailedMissions["Global"] = errorMessage
-		return errors.New(errorMessage)
+		sendMissionMessage.FailedMissions["Global"] = err.Error()
+		return err
 	}
 
 	for _, startPlanet := range request.StartPlanets {
@@ -82,20 +81,119 @@ func parseAction(request *Request) error {
 	return nil
 }
 
+// Answers what would happen if the missions are started right now, without
+// actually starting any of them.
+func previewMission(request *Request) error {
+	previews := response.NewMissionPreviews()
+
+	endPlanet, err := missionTarget(request)
+	if err != nil {
+		return err
+	}
+
+	for _, startPlanet := range request.StartPlanets {
+		_, mission, err := planMission(startPlanet, endPlanet, request)
+		if err == nil {
+			previews.Previews[startPlanet] = response.NewMissionPreview(mission, endPlanet, request.Client.Player)
+		} else {
+			previews.FailedMissions[startPlanet] = err.Error()
+		}
+	}
+
+	request.Client.Send(previews)
+	return nil
+}
+
+// Checks everything in a mission request, which is common for all
+// of its start planets and returns the target of the missions.
+func missionTarget(request *Request) (*entities.Planet, error) {
+	if len(request.StartPlanets) == 0 {
+		return nil, errors.New("No start planets provided")
+	}
+
+	entity, err := request.Client.universe.World().Get(request.EndPlanet)
+	if err != nil {
+		return nil, errors.New("End planet does not exist")
+	}
+	target, ok := entity.(*entities.Planet)
+	if !ok {
+		return nil, errors.New("End planet does not exist")
+	}
+
+	if _, isMissionTypeValid := missionTypes[request.Type]; !isMissionTypeValid {
+		return nil, errors.New("Invalid mission type!")
+	}
+	return target, nil
+}
+
 func prepareMission(startPlanet string, endPlanet *entities.Planet, request *Request) (*entities.Mission, error) {
-	sourceEntity, err := entities.Get(startPlanet)
+	source, mission, err := planMission(startPlanet, endPlanet, request)
 	if err != nil {
 		return nil, err
 	}
 
-	source := sourceEntity.(*entities.Planet)
+	u := request.Client.universe
+	player := request.Client.Player
+	if attacksOthers(request, endPlanet) && player.IsProtected() {
+		// Whoever attacks is not a newbie anymore
+		source.ProtectedUntil = 0
+		for _, planet := range u.World().EndProtection(player) {
+			if planet.Name != source.Name {
+				u.Clients().Broadcast(planet)
+			}
+		}
+	}
+	u.World().Save(source)
+	u.spawnMissionary(mission)
+	u.World().Save(mission)
+	u.Clients().Broadcast(mission)
+	u.Clients().Broadcast(source)
+	u.recordMission(mission)
+
+	return mission, nil
+}
+
+// Returns true if the requested missions attack a planet of someone else.
+func attacksOthers(request *Request, endPlanet *entities.Planet) bool {
+	return request.Type == "Attack" && endPlanet.HasOwner() && endPlanet.Owner != request.Client.Player.Username
+}
+
+// Creates the mission from the given start planet and checks if it could
+// be started. Nothing is saved, so it's up to the caller to start it.
+// Previews go through the very same checks, so they give the same answer.
+func planMission(startPlanet string, endPlanet *entities.Planet, request *Request) (*entities.Planet, *entities.Mission, error) {
+	if err := request.Client.universe.checkTournament(); err != nil {
+		return nil, nil, err
+	}
+	if request.Client.Player.OnVacation() {
+		return nil, nil, errors.New("The player is on vacation.")
+	}
+	if attack := attacksOthers(request, endPlanet); attack && endPlanet.IsProtected() {
+		return nil, nil, errors.New("The planet is under newbie protection.")
+	} else if attack && endPlanet.OnVacation {
+		return nil, nil, errors.New("The owner of the planet is on vacation.")
+	}
+
+	world := request.Client.universe.World()
+	sourceEntity, err := world.Get(startPlanet)
+	if err != nil {
+		return nil, nil, err
+	}
+	source, ok := sourceEntity.(*entities.Planet)
+	if !ok {
+		return nil, nil, errors.New("Start planet does not exist")
+	}
 
 	if source.Owner != request.Client.Player.Username {
-		return nil, errors.New("The mission owner does not own the start planet.")
+		return nil, nil, errors.New("The mission owner does not own the start planet.")
 	}
 
 	if startPlanet == request.EndPlanet {
-		return nil, errors.New("Start and end planet are the same.")
+		return nil, nil, errors.New("Start and end planet are the same.")
+	}
+
+	if err := world.ValidatePath(source.Position, endPlanet.Position, request.Path); err != nil {
+		return nil, nil, err
 	}
 
 	mission := request.Client.Player.StartMission(
@@ -107,14 +205,99 @@ func prepareMission(startPlanet string, endPlanet *entities.Planet, request *Req
 	)
 
 	if mission.ShipCount == 0 {
-		return nil, errors.New("Not enough pilots on source planet!")
+		return nil, nil, errors.New("Not enough pilots on source planet!")
 	}
 
-	entities.Save(source)
-	go StartMissionary(mission)
-	entities.Save(mission)
-	clients.Broadcast(mission)
-	clients.Broadcast(source)
+	if err := source.SpendEnergy(world.MissionEnergyCost(request.Type, request.Boost)); err != nil {
+		return nil, nil, err
+	}
+	if request.Boost {
+		mission.Boost()
+	}
+	if mission.Type == "Spy" {
+		mission.Station(
+			time.Duration(request.ReportInterval),
+			time.Duration(request.SpyDuration),
+		)
+	}
 
-	return mission, nil
+	return source, mission, nil
+}
+
+// Calls the spies of a spy station back home.
+func recallSpies(request *Request) error {
+	if err := request.Client.universe.checkTournament(); err != nil {
+		return err
+	}
+
+	entity, err := request.Client.universe.World().Get(request.Mission)
+	if err != nil {
+		return errors.New("Mission does not exist")
+	}
+	mission, ok := entity.(*entities.Mission)
+	if !ok || mission.Player != request.Client.Player.Username {
+		return errors.New("The player does not own the mission.")
+	}
+
+	if !spyStations.recall(mission.Key()) {
+		return errors.New("The spies haven't reached their target yet.")
+	}
+	return nil
+}
+
+// Sends the latest events of the player's history.
+func history(request *Request) error {
+	request.Client.Send(response.NewHistory(
+		request.Client.universe.World().PlayerHistory(request.Client.Player.Username),
+	))
+	return nil
+}
+
+// Starts building the next level of a building on one of the player's
+// planets. Everyone sees the planet once the upgrade starts and once more
+// when it is ready.
+func upgradePlanet(request *Request) error {
+	u := request.Client.universe
+	if err := u.checkTournament(); err != nil {
+		return err
+	}
+
+	entity, err := u.World().Get(request.Planet)
+	if err != nil {
+		return errors.New("Planet does not exist")
+	}
+	planet, ok := entity.(*entities.Planet)
+	if !ok {
+		return errors.New("Planet does not exist")
+	}
+
+	if planet.Owner != request.Client.Player.Username {
+		return errors.New("The player does not own the planet.")
+	}
+
+	construction, err := planet.StartUpgrade(request.Building)
+	if err != nil {
+		return err
+	}
+
+	u.World().Save(planet)
+	u.Clients().Broadcast(planet)
+	clock.Go(func(c clock.Clock) { u.finishConstruction(c, planet.Key(), construction) })
+	return nil
+}
+
+// Waits for the construction to finish and broadcasts the upgraded planet.
+// The upgrade itself is applied by UpdateShipCount, so nothing is lost if
+// the server is restarted in the meantime.
+func (u *Universe) finishConstruction(c clock.Clock, planetKey string, construction *entities.Construction) {
+	clock.SleepOn(c, time.Unix(construction.FinishesAt, 0).Sub(c.Now()))
+
+	entity, err := u.World().Get(planetKey)
+	if err != nil {
+		return
+	}
+	planet := entity.(*entities.Planet)
+	planet.UpdateShipCount()
+	u.World().Save(planet)
+	u.Clients().Broadcast(planet)
 }
@@ -0,0 +1,18 @@
+package response
+
+import "warcluster/entities"
+
+// History holds the latest events of the player, latest first.
+type History struct {
+	baseResponse
+	Entries []*entities.HistoryEntry
+}
+
+func NewHistory(entries []*entities.HistoryEntry) *History {
+	r := new(History)
+	r.Command = "history"
+	r.Entries = entries
+	return r
+}
+
+func (h *History) Sanitize(*entities.Player) {}
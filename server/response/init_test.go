@@ -0,0 +1,16 @@
+package response
+
+import (
+	"log"
+
+	"warcluster/config"
+	"warcluster/entities"
+)
+
+func init() {
+	var cfg config.Config
+	if err := cfg.Load(); err != nil {
+		log.Fatal(err)
+	}
+	entities.ExportConfig(cfg)
+}
@@ -15,6 +15,8 @@ type LoginSuccess struct {
 		Name     string
 		Position *vec2d.Vector
 	}
+	ProtectedUntil int64 `json:",omitempty"` // End of the newbie protection in ms
+	VacationStart  int64 `json:",omitempty"` // Start of the vacation in ms
 }
 
 type LoginFailed struct {
@@ -31,6 +33,10 @@ func NewLoginSuccess(player *entities.Player, homePlanet *entities.Planet) *Logi
 	r.Username = player.Username
 	r.RaceID = player.RaceID
 	r.Position = player.ScreenPosition
+	if player.IsProtected() {
+		r.ProtectedUntil = player.ProtectedUntil
+	}
+	r.VacationStart = player.VacationStart
 	r.HomePlanet.Name = homePlanet.Name
 	r.HomePlanet.Position = homePlanet.Position
 	return r
@@ -3,10 +3,10 @@ package response
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/pzsz/voronoi"
 
+	"warcluster/clock"
 	"warcluster/entities"
 )
 
@@ -24,7 +24,7 @@ type baseResponse struct {
 }
 
 func (t *Timestamp) MarshalJSON() ([]byte, error) {
-	return json.Marshal(time.Now().UnixNano() / 1e6)
+	return json.Marshal(clock.NowMs())
 }
 
 // The sanitizer recieves raw planet and obscures hidden for the player information
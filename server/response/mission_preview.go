@@ -0,0 +1,55 @@
+package response
+
+import (
+	"time"
+
+	"warcluster/entities"
+)
+
+// MissionPreview is what would happen if a mission is started right now.
+type MissionPreview struct {
+	ShipCount        int32
+	Speed            float64
+	TravelTime       time.Duration // in ms.
+	ArrivalTime      int64         // in ms.
+	Areas            []string
+	DefenderStrength int32 // -1 when there is no valid spy report
+}
+
+type MissionPreviews struct {
+	baseResponse
+	Previews       map[string]*MissionPreview `json:",omitempty"`
+	FailedMissions map[string]string          `json:",omitempty"`
+}
+
+func NewMissionPreviews() *MissionPreviews {
+	r := new(MissionPreviews)
+	r.Command = "mission_preview"
+	r.Previews = make(map[string]*MissionPreview)
+	r.FailedMissions = make(map[string]string)
+	return r
+}
+
+// Builds the preview of the given mission. The defender strength is
+// estimated from the latest valid spy report of the player about the
+// target, if there is such.
+func NewMissionPreview(mission *entities.Mission, target *entities.Planet, player *entities.Player) *MissionPreview {
+	preview := &MissionPreview{
+		ShipCount:        mission.ShipCount,
+		Speed:            mission.Speed,
+		TravelTime:       mission.TravelTime,
+		ArrivalTime:      mission.StartTime + int64(mission.TravelTime),
+		Areas:            mission.Areas(),
+		DefenderStrength: -1,
+	}
+
+	if target.Owner == player.Username {
+		preview.DefenderStrength = 0
+	} else if report := player.SpyReport(target.Name); report != nil {
+		arrival := time.Unix(0, preview.ArrivalTime*1e6)
+		preview.DefenderStrength = report.EstimateDefence(target, arrival)
+	}
+	return preview
+}
+
+func (m *MissionPreviews) Sanitize(*entities.Player) {}
@@ -0,0 +1,22 @@
+package response
+
+import "warcluster/entities"
+
+// PlayerEliminated is sent to the player whose home planet has just been
+// taken. They could come back with a respawn request in a new solar
+// system, or they are placed again on their next login.
+type PlayerEliminated struct {
+	baseResponse
+	Planet string
+	By     string
+}
+
+func NewPlayerEliminated(planet *entities.Planet) *PlayerEliminated {
+	r := new(PlayerEliminated)
+	r.Command = "player_eliminated"
+	r.Planet = planet.Name
+	r.By = planet.Owner
+	return r
+}
+
+func (r *PlayerEliminated) Sanitize(*entities.Player) {}
@@ -0,0 +1,37 @@
+package response
+
+import (
+	"warcluster/entities"
+	"warcluster/replay"
+)
+
+// ReplayEvent carries a single recorded event to a spectator watching a
+// replay. Recorded games are shown as they are, nothing is hidden.
+type ReplayEvent struct {
+	baseResponse
+	Event *replay.Event
+}
+
+func NewReplayEvent(event *replay.Event) *ReplayEvent {
+	r := new(ReplayEvent)
+	r.Command = "replay_event"
+	r.Event = event
+	return r
+}
+
+func (r *ReplayEvent) Sanitize(*entities.Player) {}
+
+// ReplayEnd is sent once the whole game has been replayed.
+type ReplayEnd struct {
+	baseResponse
+	Events int
+}
+
+func NewReplayEnd(events int) *ReplayEnd {
+	r := new(ReplayEnd)
+	r.Command = "replay_end"
+	r.Events = events
+	return r
+}
+
+func (r *ReplayEnd) Sanitize(*entities.Player) {}
@@ -30,10 +30,10 @@ func (s *ScopeOfView) Areas() []string {
 }
 
 // TODO: Use vector for rawPlanets
-func NewScopeOfView(position *vec2d.Vector, resolution []uint64) *ScopeOfView {
+func NewScopeOfView(world *entities.World, position *vec2d.Vector, resolution []uint64) *ScopeOfView {
 	topLeft, bottomRight := calculateCanvasSize(position, resolution)
 	areas := listAreas(topLeft, bottomRight)
-	entityList := entities.GetAreasMembers(areas)
+	entityList := world.GetAreasMembers(areas)
 
 	s := new(ScopeOfView)
 	s.Command = "scope_of_view_result"
@@ -62,13 +62,13 @@ func NewScopeOfView(position *vec2d.Vector, resolution []uint64) *ScopeOfView {
 // calculateCanvasSize is used to determine where is the viewable by client's area
 func calculateCanvasSize(position *vec2d.Vector, resolution []uint64) (*vec2d.Vector, *vec2d.Vector) {
 	topLeft := vec2d.New(
-		position.X-float64(resolution[0]+entities.Settings.SunCanvasOffsetX)/2,
-		position.Y+float64(resolution[1]+entities.Settings.SunCanvasOffsetY)/2,
+		position.X-float64(resolution[0]+entities.Settings().SunCanvasOffsetX)/2,
+		position.Y+float64(resolution[1]+entities.Settings().SunCanvasOffsetY)/2,
 	)
 
 	bottomRight := vec2d.New(
-		position.X+float64(resolution[0]+entities.Settings.SunCanvasOffsetX)/2,
-		position.Y-float64(resolution[1]+entities.Settings.SunCanvasOffsetY)/2,
+		position.X+float64(resolution[0]+entities.Settings().SunCanvasOffsetX)/2,
+		position.Y-float64(resolution[1]+entities.Settings().SunCanvasOffsetY)/2,
 	)
 	return topLeft, bottomRight
 }
@@ -0,0 +1,19 @@
+package response
+
+import "warcluster/entities"
+
+// SeasonEnd is sent to everyone online right before they are disconnected
+// at the end of a season. They are placed again on their next login.
+type SeasonEnd struct {
+	baseResponse
+	Season int
+}
+
+func NewSeasonEnd(season int) *SeasonEnd {
+	r := new(SeasonEnd)
+	r.Command = "season_end"
+	r.Season = season
+	return r
+}
+
+func (r *SeasonEnd) Sanitize(*entities.Player) {}
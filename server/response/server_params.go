@@ -3,6 +3,7 @@ package response
 import (
 	"fmt"
 
+	"warcluster/config"
 	"warcluster/entities"
 )
 
@@ -11,12 +12,29 @@ type ServerParams struct {
 	HomeSPM            float64 //ships per minute
 	PlanetsSPM         map[string]float64
 	ShipsDeathModifier float64
-	Races              map[string]entities.Race
+	Production         config.ProductionTable
+	Buildings          config.BuildingTable
+	MissionBoost       struct {
+		Energy float64
+		Speed  float64
+	}
+	SpyEnergy            float64
+	MinSpyReportInterval int64 // in seconds
+	SpeedModel           struct {
+		Formula      string
+		DefaultSpeed int64 // of the mission types without a curve
+		Curves       config.SpeedTable
+	}
+	PathLimits struct {
+		MaxWaypoints  int
+		MaxPathLength float64
+		MaxCoordinate float64
+	}
+	Races map[string]entities.Race
 }
 
-func NewServerParams() *ServerParams {
-	var planetSizeIdx int8
-
+// Returns the params of the given world.
+func NewServerParams(world *entities.World) *ServerParams {
 	r := new(ServerParams)
 	r.Races = make(map[string]entities.Race)
 	r.PlanetsSPM = make(map[string]float64)
@@ -26,12 +44,29 @@ func NewServerParams() *ServerParams {
 	for _, race := range entities.Races {
 		r.Races[race.Name] = race
 	}
-	r.HomeSPM = 60 / float64(entities.ShipCountTimeMod(1, true))
-	for planetSizeIdx = 1; planetSizeIdx <= 10; planetSizeIdx++ {
-		planetSPM := float64(entities.ShipCountTimeMod(planetSizeIdx, false))
-		r.PlanetsSPM[fmt.Sprintf("%v", planetSizeIdx)] = 60 / planetSPM
+
+	// HomeSPM, PlanetsSPM and ShipsDeathModifier are there for the clients
+	// which don't know about the production table yet.
+	settings := world.Settings()
+	production := settings.Production
+	r.Production = production
+	r.Buildings = settings.Buildings
+	r.MissionBoost.Energy = settings.MissionBoostEnergy
+	r.MissionBoost.Speed = settings.MissionBoostSpeed
+	r.SpyEnergy = settings.SpyEnergy
+	r.MinSpyReportInterval = int64(settings.MinSpyReportInterval)
+	r.SpeedModel.Formula = config.SpeedFormula
+	r.SpeedModel.DefaultSpeed = settings.MissionSpeed
+	r.SpeedModel.Curves = settings.Speeds
+	r.PathLimits.MaxWaypoints = settings.MaxWaypoints
+	r.PathLimits.MaxPathLength = settings.MaxPathLength
+	r.PathLimits.MaxCoordinate = settings.MaxCoordinate
+	r.HomeSPM = 60 / float64(world.ShipCountTimeMod(0, true))
+	for _, size := range production.Sizes() {
+		planetSPM := float64(world.ShipCountTimeMod(size, false))
+		r.PlanetsSPM[fmt.Sprintf("%v", size)] = 60 / planetSPM
 	}
-	r.ShipsDeathModifier = entities.Settings.ShipsDeathModifier
+	r.ShipsDeathModifier = world.PlanetProduction(0, true).DeathModifier
 	return r
 }
 
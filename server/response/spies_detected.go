@@ -0,0 +1,34 @@
+package response
+
+import "warcluster/entities"
+
+// SpiesDetected is pushed to both the owner of the planet and the player
+// who sent the spies once they are caught above it.
+type SpiesDetected struct {
+	baseResponse
+	Mission   string
+	Spy       string
+	Owner     string
+	Killed    int32
+	ShipsLeft int32
+	Destroyed bool
+	rawPlanet *entities.Planet
+	Planet    *entities.PlanetPacket
+}
+
+func NewSpiesDetected(mission *entities.Mission, killed int32, planet *entities.Planet) *SpiesDetected {
+	r := new(SpiesDetected)
+	r.Command = "spies_detected"
+	r.Mission = mission.Key()
+	r.Spy = mission.Player
+	r.Owner = planet.Owner
+	r.Killed = killed
+	r.ShipsLeft = mission.ShipCount
+	r.Destroyed = mission.ShipCount == 0
+	r.rawPlanet = planet
+	return r
+}
+
+func (s *SpiesDetected) Sanitize(player *entities.Player) {
+	s.Planet = s.rawPlanet.Sanitize(player)
+}
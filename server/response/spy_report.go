@@ -0,0 +1,27 @@
+package response
+
+import "warcluster/entities"
+
+// SpyReport is pushed to the player every time a spy station reports.
+type SpyReport struct {
+	baseResponse
+	Mission     string
+	ReportsLeft int32
+	Report      *entities.SpyReport
+	rawPlanet   *entities.Planet
+	Planet      *entities.PlanetPacket
+}
+
+func NewSpyReport(mission *entities.Mission, report *entities.SpyReport, planet *entities.Planet) *SpyReport {
+	r := new(SpyReport)
+	r.Command = "spy_report"
+	r.Mission = mission.Key()
+	r.ReportsLeft = mission.ReportsLeft
+	r.Report = report
+	r.rawPlanet = planet
+	return r
+}
+
+func (s *SpyReport) Sanitize(player *entities.Player) {
+	s.Planet = s.rawPlanet.Sanitize(player)
+}
@@ -0,0 +1,31 @@
+package response
+
+import (
+	"time"
+
+	"warcluster/entities"
+)
+
+// TournamentStatus tells the players in a universe hosting a tournament
+// whether it is pending, running or finished. Start and End are in ms.
+// The winner is known once it's finished.
+type TournamentStatus struct {
+	baseResponse
+	State        string
+	Start        int64
+	End          int64
+	WinCondition string
+	Winner       string `json:",omitempty"`
+}
+
+func NewTournamentStatus(state string, start, end time.Time, winCondition string) *TournamentStatus {
+	r := new(TournamentStatus)
+	r.Command = "tournament_status"
+	r.State = state
+	r.Start = start.UnixNano() / 1e6
+	r.End = end.UnixNano() / 1e6
+	r.WinCondition = winCondition
+	return r
+}
+
+func (r *TournamentStatus) Sanitize(*entities.Player) {}
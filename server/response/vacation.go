@@ -0,0 +1,29 @@
+package response
+
+import "warcluster/entities"
+
+// Vacation tells the player whether they are on vacation. All times are in
+// ms: when the vacation has started, when it could end at the earliest and
+// when the next one could start. The unknown ones are left out.
+type Vacation struct {
+	baseResponse
+	OnVacation   bool
+	Since        int64 `json:",omitempty"`
+	MinEnd       int64 `json:",omitempty"`
+	NextVacation int64 `json:",omitempty"`
+}
+
+func NewVacation(world *entities.World, player *entities.Player) *Vacation {
+	r := new(Vacation)
+	r.Command = "vacation"
+	r.OnVacation = player.OnVacation()
+	if r.OnVacation {
+		r.Since = player.VacationStart
+		r.MinEnd = world.VacationMinEnd(player)
+	} else {
+		r.NextVacation = world.NextVacation(player)
+	}
+	return r
+}
+
+func (r *Vacation) Sanitize(*entities.Player) {}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/Vladimiroff/vec2d"
 	"github.com/garyburd/redigo/redis"
@@ -11,6 +13,7 @@ import (
 
 	"warcluster/entities"
 	"warcluster/entities/db"
+	"warcluster/server/response"
 )
 
 var gophie entities.Player = entities.Player{
@@ -144,6 +147,146 @@ func (suite *ResponseTestSuite) TestParseActionWithMultipleStartPlanet() {
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *ResponseTestSuite) TestUpgradePlanet() {
+	suite.request.Command = "upgrade_planet"
+	suite.request.Planet = "planet.GOP6720"
+	suite.request.Building = "radar"
+	planet1.SetShipCount(1000)
+	entities.Save(&planet1)
+
+	err := upgradePlanet(suite.request)
+	assert.Nil(suite.T(), err)
+
+	entity, _ := entities.Get("planet.GOP6720")
+	construction := entity.(*entities.Planet).Construction
+	if assert.NotNil(suite.T(), construction) {
+		assert.Equal(suite.T(), "radar", construction.Building)
+	}
+}
+
+func (suite *ResponseTestSuite) TestUpgradeForeignPlanet() {
+	suite.request.Command = "upgrade_planet"
+	suite.request.Planet = "planet.PAN6720"
+	suite.request.Building = "radar"
+
+	err := upgradePlanet(suite.request)
+
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *ResponseTestSuite) TestUpgradeNotAPlanet() {
+	suite.request.Command = "upgrade_planet"
+	suite.request.Planet = "player.gophie"
+	suite.request.Building = "radar"
+
+	err := upgradePlanet(suite.request)
+
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), "Planet does not exist", err.Error())
+	}
+}
+
+func (suite *ResponseTestSuite) TestParseActionWithInvalidPath() {
+	fakeClient := NewFakeClient(&gophie)
+	suite.request.Client = fakeClient
+	suite.request.Path = []*vec2d.Vector{vec2d.New(4, 4), nil}
+
+	err := parseAction(suite.request)
+	assert.Nil(suite.T(), err)
+
+	var sendMissions response.SendMissions
+	codec := fakeClient.codec.(*fakeCodec)
+	json.Unmarshal(codec.Messages[0], &sendMissions)
+	assert.Equal(suite.T(), "Waypoint 2 is missing.", sendMissions.FailedMissions["planet.GOP6720"])
+	assert.Len(suite.T(), entities.Find("mission.*"), 0)
+}
+
+func (suite *ResponseTestSuite) TestPreviewMission() {
+	source := planet1
+	source.ShipCount = 100
+	source.MaxShipCount = 1000
+	source.LastShipCountUpdate = time.Now().Unix()
+	entities.Save(&source)
+
+	fakeClient := NewFakeClient(&gophie)
+	suite.request.Client = fakeClient
+	suite.request.Command = "preview_mission"
+	suite.request.Type = "Attack"
+
+	err := previewMission(suite.request)
+	assert.Nil(suite.T(), err)
+
+	assert.Len(suite.T(), entities.Find("mission.*"), 0)
+	entity, _ := entities.Get(source.Key())
+	assert.Equal(suite.T(), int32(100), entity.(*entities.Planet).ShipCount)
+
+	var previews response.MissionPreviews
+	codec := fakeClient.codec.(*fakeCodec)
+	json.Unmarshal(codec.Messages[0], &previews)
+	preview := previews.Previews[source.Key()]
+	if assert.NotNil(suite.T(), preview) {
+		assert.Equal(suite.T(), int32(32), preview.ShipCount)
+		assert.Equal(suite.T(), int32(-1), preview.DefenderStrength)
+		assert.NotEmpty(suite.T(), preview.Areas)
+	}
+}
+
+func (suite *ResponseTestSuite) TestPreviewMissionOfNotPlanets() {
+	fakeClient := NewFakeClient(&gophie)
+	suite.request.Client = fakeClient
+	suite.request.Command = "preview_mission"
+	suite.request.Type = "Attack"
+
+	suite.request.EndPlanet = "player.panda"
+	err := previewMission(suite.request)
+	if assert.NotNil(suite.T(), err) {
+		assert.Equal(suite.T(), "End planet does not exist", err.Error())
+	}
+
+	suite.request.EndPlanet = "planet.PAN6720"
+	suite.request.StartPlanets = []string{"player.gophie"}
+	assert.Nil(suite.T(), previewMission(suite.request))
+
+	var previews response.MissionPreviews
+	codec := fakeClient.codec.(*fakeCodec)
+	json.Unmarshal(codec.Messages[0], &previews)
+	assert.Equal(suite.T(), "Start planet does not exist", previews.FailedMissions["player.gophie"])
+}
+
+func (suite *ResponseTestSuite) TestRecallSpiesBeforeArrival() {
+	mission := &entities.Mission{Type: "Spy", Player: "gophie", ShipCount: 5, StartTime: 42}
+	mission.Source.Name = "GOP6720"
+	entities.Save(mission)
+
+	suite.request.Command = "recall_spies"
+	suite.request.Mission = mission.Key()
+	assert.NotNil(suite.T(), recallSpies(suite.request))
+
+	suite.request.Client = NewFakeClient(&panda)
+	assert.NotNil(suite.T(), recallSpies(suite.request))
+}
+
+func (suite *ResponseTestSuite) TestHistory() {
+	entities.RecordHistory("gophie", entities.SpiesCaught, "PAN6720", "panda", 2)
+	entities.RecordHistory("gophie2", entities.SpiesDetected, "GOP6720", "panda", 1)
+
+	fakeClient := NewFakeClient(&gophie)
+	suite.request.Client = fakeClient
+	suite.request.Command = "history"
+	err := history(suite.request)
+	assert.Nil(suite.T(), err)
+
+	var message response.History
+	messages := fakeClient.codec.(*fakeCodec).Messages
+	if assert.Len(suite.T(), messages, 1) {
+		json.Unmarshal(messages[0], &message)
+		assert.Equal(suite.T(), "history", message.Command)
+		if assert.Len(suite.T(), message.Entries, 1) {
+			assert.Equal(suite.T(), "PAN6720", message.Entries[0].Planet)
+		}
+	}
+}
+
 func TestResponseTestSuite(t *testing.T) {
 	suite.Run(t, new(ResponseTestSuite))
 }
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/leaderboard"
+	"warcluster/server/response"
+	"warcluster/snapshot"
+)
+
+// Ends the current season of the universe. The leaderboard and a snapshot
+// of the world are archived, then everything played during the season is
+// wiped out. Players keep their accounts and are placed again with the
+// same race on their next login, so everyone online is disconnected.
+// Missions on their way are called back before anything is archived, so
+// none of them lands in the new season. Running bots are stopped and have
+// to be spawned again.
+func (u *Universe) EndSeason() (*entities.Season, error) {
+	u.Missionaries().recallAll()
+	defer u.Missionaries().resume()
+
+	world := u.World()
+	seasons := world.Seasons()
+
+	season := &entities.Season{
+		Number:  len(seasons) + 1,
+		End:     clock.NowMs(),
+		Players: u.Leaderboard().Players(),
+		Races:   u.Leaderboard().Races(),
+	}
+	if len(seasons) > 0 {
+		season.Start = seasons[0].End
+	}
+
+	var archive bytes.Buffer
+	if err := json.NewEncoder(&archive).Encode(snapshot.TakeFrom(world)); err != nil {
+		return nil, err
+	}
+	season.Snapshot = archive.Bytes()
+	if err := world.Save(season); err != nil {
+		return nil, err
+	}
+
+	if err := world.WipeSeason(); err != nil {
+		return nil, err
+	}
+	u.initLeaderboard(leaderboard.New())
+	bots.despawnAll(u)
+
+	if pool := u.Clients(); pool != nil {
+		pool.SendAll(response.NewSeasonEnd(season.Number))
+		pool.DisconnectAll()
+	}
+	log.Printf("Season %d has ended", season.Number)
+	return season, nil
+}
+
+// Ends the season of the universe given with the `universe` query
+// parameter on POST. GET returns the archived snapshot of the `season`.
+func adminSeasonHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.NotFound(w, r)
+		return
+	}
+	u, err := universeOfRequest(r)
+	if err != nil {
+		http.Error(w, "Universe Not Found", 404)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		number, _ := strconv.Atoi(r.URL.Query().Get("season"))
+		entity, err := u.World().Get(fmt.Sprintf("season.%d", number))
+		if err != nil {
+			http.Error(w, "Season Not Found", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(entity.(*entities.Season).Snapshot)
+	case "POST":
+		season, err := u.EndSeason()
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		fmt.Fprintf(w, "Season %d has ended.\n", season.Number)
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+}
+
+// Returns the standings of all past seasons of the universe given with
+// the `universe` query parameter, the latest first.
+func seasonsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	u, err := universeOfRequest(r)
+	if err != nil {
+		http.Error(w, "Universe Not Found", 404)
+		return
+	}
+
+	seasons := u.World().Seasons()
+	if seasons == nil {
+		seasons = []*entities.Season{}
+	}
+	result, err := json.Marshal(seasons)
+	if err != nil {
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	fmt.Fprint(w, string(result))
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/entities/db"
+	"warcluster/snapshot"
+)
+
+func TestEndSeason(t *testing.T) {
+	defer func(token string) {
+		cfg.Admin.Token = token
+	}(cfg.Admin.Token)
+	cfg.Admin.Token = "secret"
+
+	settings := *entities.Settings()
+	settings.PirateFactions = 0
+	u, err := NewUniverse("seasons", db.NewMemoryPool(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer u.Stop()
+
+	login := &Request{Command: "login", Username: "veteran", TwitterID: "veteran"}
+	ws := dialUniverse(t, "/universe/seasons")
+	websocket.JSON.Send(ws, login)
+	receiveCommand(t, ws, "request_setup_params")
+	websocket.JSON.Send(ws, &Request{Command: "setup_parameters", Race: 1, SunTextureId: 2})
+	receiveCommand(t, ws, "login_success")
+	entity, _ := u.World().Get("player.veteran")
+	oldHome := entity.(*entities.Player).HomePlanet
+
+	season, err := u.EndSeason()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message := receiveCommand(t, ws, "season_end"); message["Season"] != float64(1) {
+		t.Errorf("Season %v has ended instead of the first one", message["Season"])
+	}
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := websocket.JSON.Receive(ws, new(Request)); err == nil {
+		t.Error("The player is still connected")
+	}
+	ws.Close()
+
+	if len(season.Players) != 1 || season.Players[0].Username != "veteran" || season.Players[0].Planets != 1 {
+		t.Errorf("The archived leaderboard is %#v", season.Players)
+	}
+	var archive snapshot.Snapshot
+	if err := json.Unmarshal(season.Snapshot, &archive); err != nil || len(archive.Players) != 1 {
+		t.Errorf("The archived snapshot is %v: %s", archive.Players, err)
+	}
+	if planets := u.World().Find("planet.*"); len(planets) != 0 {
+		t.Errorf("%d planets are left from the last season", len(planets))
+	}
+	if _, err := u.World().Get("player.veteran"); err != nil {
+		t.Error("The player has lost their account")
+	}
+	if u.Leaderboard().Len() != 0 {
+		t.Errorf("%d players are in the leaderboard of the new season", u.Leaderboard().Len())
+	}
+
+	request, _ := http.NewRequest("GET", "/seasons?universe=seasons", nil)
+	response := httptest.NewRecorder()
+	seasonsHandler(response, request)
+	if body := response.Body.String(); !strings.Contains(body, `"Username":"veteran"`) || strings.Contains(body, "Snapshot") {
+		t.Errorf("The past seasons are %s", body)
+	}
+	request, _ = http.NewRequest("GET", "/admin/season?universe=seasons&season=1&token=secret", nil)
+	response = httptest.NewRecorder()
+	adminSeasonHandler(response, request)
+	if response.Code != 200 || !strings.Contains(response.Body.String(), oldHome[len("planet."):]) {
+		t.Errorf("The archived snapshot is not served: %d", response.Code)
+	}
+
+	ws = dialUniverse(t, "/universe/seasons")
+	defer ws.Close()
+	websocket.JSON.Send(ws, login)
+	receiveCommand(t, ws, "server_params")
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message map[string]interface{}
+	if err := websocket.JSON.Receive(ws, &message); err != nil || message["Command"] != "login_success" {
+		t.Fatalf("The player is asked for %v on the first login in the season: %v", message["Command"], err)
+	}
+
+	entity, _ = u.World().Get("player.veteran")
+	player := entity.(*entities.Player)
+	if player.RaceID != 1 || player.SunTextureId != 2 {
+		t.Errorf("The player is placed again with race %d and sun %d", player.RaceID, player.SunTextureId)
+	}
+	if _, err := u.World().Get(player.HomePlanet); err != nil {
+		t.Errorf("The player has no home planet in the new season: %s", err)
+	}
+	if u.Leaderboard().Len() != 1 {
+		t.Errorf("%d players are in the leaderboard after the login", u.Leaderboard().Len())
+	}
+}
+
+func TestEndSeasonRecallsMissions(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 7, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	u := newTestUniverse(t, *entities.Settings())
+	world := u.World()
+	raider := registerTestPlayer(u, "raider")
+	victim := registerTestPlayer(u, "victim")
+	world.EndProtection(raider)
+	world.EndProtection(victim)
+
+	entity, _ := world.Get(victim.HomePlanet)
+	_, err := prepareMission(raider.HomePlanet, entity.(*entities.Planet), &Request{
+		Client:       testClient(u, raider),
+		StartPlanets: []string{raider.HomePlanet},
+		EndPlanet:    victim.HomePlanet,
+		Type:         "Attack",
+		Fleet:        100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.EndSeason(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, waiting := fake.Next(); waiting {
+		t.Error("The missionary is still on its way after the season has ended")
+	}
+	for _, pattern := range []string{"mission.*", "planet.*"} {
+		if left := world.Find(pattern); len(left) != 0 {
+			t.Errorf("%d records of %s are left from the last season", len(left), pattern)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+
+	"warcluster/clock"
+	"warcluster/entities"
+	"warcluster/entities/db"
+	"warcluster/leaderboard"
+)
+
+// How long a simulation waits for the missionaries to catch up, before
+// giving up on a step.
+const simulationStepTimeout = 5 * time.Second
+
+// Simulation plays a scripted game on an in-memory database in fake time.
+// Players are registered and send requests just like websocket clients
+// do, while Advance moves the time from one event to the next, so days
+// of game pass in milliseconds and every run ends the very same way.
+//
+// Simulations replace the database, the clock, the client pool and the
+// detection roll of the spies of the server, so only one of them could
+// run at a time and nothing else should run alongside it. The roll is
+// seeded with the start time.
+type Simulation struct {
+	Clock   *clock.Fake
+	players map[string]*Client
+
+	previousClock        clock.Clock
+	previousPool         *redis.Pool
+	previousClients      *ClientPool
+	previousLeaderboard  *leaderboard.Leaderboard
+	restoreDetectionRoll func()
+}
+
+// Starts a simulation of an empty universe at the given time.
+func NewSimulation(start time.Time) *Simulation {
+	s := &Simulation{
+		Clock:                clock.NewFake(start),
+		players:              make(map[string]*Client),
+		previousPool:         db.Pool,
+		previousClients:      clients,
+		previousLeaderboard:  leaderBoard,
+		restoreDetectionRoll: entities.SeedDetectionRoll(start.UnixNano()),
+	}
+
+	db.InitMemoryPool()
+	s.previousClock = clock.Set(s.Clock)
+	clients = NewClientPool(13)
+	InitLeaderboard(leaderboard.New())
+	return s
+}
+
+// Registers a new player, who is online until the simulation ends.
+func (s *Simulation) Register(username string, race uint8) (*entities.Player, error) {
+	if _, ok := s.players[username]; ok {
+		return nil, fmt.Errorf("%s is already registered", username)
+	}
+	setupData := &entities.SetupData{Race: race}
+	if err := setupData.Validate(); err != nil {
+		return nil, err
+	}
+
+	player := defaultUniverse.register(setupData, username, "", nil)
+	client := NewClient(nil, player, nil)
+	client.codec = new(botCodec)
+	s.players[username] = client
+
+	clients.Add(client)
+	player.UpdateSpyReports()
+	return player, nil
+}
+
+// Handles the request as if the player has sent it over the websocket.
+func (s *Simulation) Send(username string, request *Request) error {
+	client, ok := s.players[username]
+	if !ok {
+		return fmt.Errorf("%s is not registered", username)
+	}
+	if err := s.Clock.WaitIdle(simulationStepTimeout); err != nil {
+		return err
+	}
+
+	request.Client = client
+	action, err := ParseRequest(request)
+	if err != nil {
+		return err
+	}
+	if err := action(request); err != nil {
+		return err
+	}
+	recordCommand(request)
+	return nil
+}
+
+// Moves the time forward. Everything happening until then, like missions
+// arriving, happens one by one in the order it would in a real game.
+func (s *Simulation) Advance(d time.Duration) error {
+	target := s.Clock.Now().Add(d)
+	for {
+		if err := s.Clock.WaitIdle(simulationStepTimeout); err != nil {
+			return err
+		}
+		next, ok := s.Clock.Next()
+		if !ok || next.After(target) {
+			break
+		}
+		s.Clock.Step()
+	}
+	s.Clock.Advance(target.Sub(s.Clock.Now()))
+	return nil
+}
+
+// Returns the planet with the given name, with its ship count up to date.
+func (s *Simulation) Planet(name string) (*entities.Planet, error) {
+	entity, err := entities.Get(fmt.Sprintf("planet.%s", name))
+	if err != nil {
+		return nil, err
+	}
+	planet, ok := entity.(*entities.Planet)
+	if !ok {
+		return nil, errors.New("Not a planet")
+	}
+	planet.UpdateShipCount()
+	return planet, nil
+}
+
+// Returns the player with the given username.
+func (s *Simulation) Player(username string) (*entities.Player, error) {
+	client, ok := s.players[username]
+	if !ok {
+		return nil, fmt.Errorf("%s is not registered", username)
+	}
+	return client.Player, nil
+}
+
+// Puts back the database, the clock, the client pool and the detection
+// roll used before the simulation. Missions still on their way are never
+// finished.
+func (s *Simulation) Close() {
+	for _, client := range s.players {
+		clients.Remove(client)
+	}
+	clock.Set(s.previousClock)
+	db.Pool = s.previousPool
+	clients = s.previousClients
+	leaderBoard = s.previousLeaderboard
+	s.restoreDetectionRoll()
+}
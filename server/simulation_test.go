@@ -0,0 +1,129 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"warcluster/entities"
+)
+
+type simulatedPlanet struct {
+	Owner     string
+	ShipCount int32
+}
+
+// Plays a short game between two players. Alice captures the nearest
+// neutral planet and supplies it from the home planet, while Bob's attack
+// on the nearest neutral planet fails, because too few ships are sent.
+func playSimulation(t *testing.T) map[string]simulatedPlanet {
+	s := NewSimulation(time.Date(2014, time.March, 1, 12, 0, 0, 0, time.UTC))
+	defer s.Close()
+
+	alice, err := s.Register("alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := s.Register("bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aliceTarget := nearestNeutralPlanet(t, s, alice)
+	bobTarget := nearestNeutralPlanet(t, s, bob)
+
+	if err := s.Advance(10 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	err = s.Send("alice", &Request{
+		Command:      "start_mission",
+		StartPlanets: []string{alice.HomePlanet},
+		EndPlanet:    aliceTarget,
+		Type:         "Attack",
+		Fleet:        80,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Send("bob", &Request{
+		Command:      "start_mission",
+		StartPlanets: []string{bob.HomePlanet},
+		EndPlanet:    bobTarget,
+		Type:         "Attack",
+		Fleet:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Advance(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	err = s.Send("alice", &Request{
+		Command:      "start_mission",
+		StartPlanets: []string{alice.HomePlanet},
+		EndPlanet:    aliceTarget,
+		Type:         "Supply",
+		Fleet:        50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Advance(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(map[string]simulatedPlanet)
+	for _, key := range []string{alice.HomePlanet, aliceTarget, bob.HomePlanet, bobTarget} {
+		planet, err := s.Planet(key[len("planet."):])
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[key] = simulatedPlanet{planet.Owner, planet.ShipCount}
+	}
+	return result
+}
+
+// Returns the key of the nearest neutral planet to the player's home planet.
+func nearestNeutralPlanet(t *testing.T, s *Simulation, player *entities.Player) string {
+	home, err := s.Planet(player.HomePlanet[len("planet."):])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var planets []*entities.PlanetPacket
+	for _, entity := range entities.Find("planet.*") {
+		planet := entity.(*entities.Planet)
+		if planet.Owner == "" {
+			planets = append(planets, planet.Sanitize(player))
+		}
+	}
+	nearest := nearestPlanet(home.Position, planets, nil)
+	if nearest == nil {
+		t.Fatalf("There are no neutral planets around %s", home.Name)
+	}
+	return nearest.Key()
+}
+
+func TestSimulation(t *testing.T) {
+	defer setSpyDetection(0)()
+
+	expected := map[string]simulatedPlanet{
+		"planet.ALI2742": {"alice", 586},
+		"planet.ALI2744": {"alice", 839},
+		"planet.BOB9037": {"", 50},
+		"planet.BOB9039": {"bob", 1176},
+	}
+
+	// The second game makes sure nothing is left behind by the first one.
+	for game := 1; game <= 2; game++ {
+		result := playSimulation(t)
+		if len(result) != len(expected) {
+			t.Fatalf("Game %d ended with %#v", game, result)
+		}
+		for key, planet := range expected {
+			if result[key] != planet {
+				t.Errorf("Game %d: %s ended as %#v instead of %#v", game, key, result[key], planet)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/server/response"
+)
+
+// Number of spectators connected since the start, used to tell them apart.
+var spectatorsCount uint64
+
+// Creates a client for a spectator. Spectators are not players, they don't
+// log in, don't show up in the leaderboard and see every planet as it is.
+func NewSpectator(ws *websocket.Conn) *Client {
+	client := NewClient(ws, nil, nil)
+	client.spectator = fmt.Sprintf("spectator.%d", atomic.AddUint64(&spectatorsCount, 1))
+	return client
+}
+
+// Handles the websockets of the spectators. They need the admin token and
+// could only look around with scope_of_view, after which they receive
+// every state change in sight, nothing sanitized. They watch the default
+// universe, unless another one is given with ?universe=<id>.
+func SpectateHandle(ws *websocket.Conn) {
+	defer func() {
+		if panicked := recover(); panicked != nil {
+			log.Println(fmt.Sprintf("%s\n\nStacktrace:\n\n%s", panicked, debug.Stack()))
+			return
+		}
+	}()
+	defer ws.Close()
+
+	if !isAdmin(ws.Request()) {
+		websocket.JSON.Send(ws, response.NewError("Spectators need the admin token"))
+		return
+	}
+
+	u, err := universeOfRequest(ws.Request())
+	if err != nil {
+		websocket.JSON.Send(ws, response.NewError(err.Error()))
+		return
+	}
+
+	client := NewSpectator(ws)
+	client.universe = u
+	u.Clients().AddSpectator(client)
+	defer u.Clients().RemoveSpectator(client)
+
+	client.Send(response.NewServerParams(u.World()))
+	for {
+		var request Request
+		if err := websocket.JSON.Receive(ws, &request); err != nil {
+			log.Println("Error in server.SpectateHandle.Receive:", err.Error())
+			return
+		}
+		request.Client = client
+		if err := spectate(&request); err != nil {
+			client.Send(response.NewError(err.Error()))
+		}
+	}
+}
+
+// Handles a request of a spectator. Everything but scope_of_view is a game
+// command, which spectators are not allowed to issue.
+func spectate(request *Request) error {
+	if request.Command != "scope_of_view" {
+		return errors.New("Spectators could only change their scope of view")
+	}
+	if _, err := ParseRequest(request); err != nil {
+		return err
+	}
+
+	response := response.NewScopeOfView(request.Client.universe.World(), request.Position, request.Resolution)
+	request.Client.MoveToAreas(response.Areas())
+	request.Client.Send(response)
+	return nil
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+	"golang.org/x/net/websocket"
+
+	"warcluster/entities"
+)
+
+func dialSpectator(t *testing.T, token string) *websocket.Conn {
+	ws, err := websocket.Dial("ws://localhost:7013/spectate?token="+token, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+// Receives messages until one with the given command arrives.
+func receiveCommand(t *testing.T, ws *websocket.Conn, command string) map[string]interface{} {
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		message := make(map[string]interface{})
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			t.Fatalf("Did not receive %s: %s", command, err)
+		}
+		if message["Command"] == command {
+			return message
+		}
+	}
+}
+
+func TestSpectator(t *testing.T) {
+	defer func(token string) {
+		cfg.Admin.Token = token
+	}(cfg.Admin.Token)
+	cfg.Admin.Token = "secret"
+
+	ws := dialSpectator(t, "wrong")
+	receiveCommand(t, ws, "error")
+	ws.Close()
+
+	planet := &entities.Planet{
+		Name:                "GOP6723",
+		Position:            vec2d.New(100, 100),
+		Owner:               "gophie",
+		ShipCount:           42,
+		MaxShipCount:        1000,
+		LastShipCountUpdate: time.Now().Unix(),
+	}
+	entities.Save(planet)
+	defer entities.Delete(planet.Key())
+
+	ws = dialSpectator(t, "secret")
+	defer ws.Close()
+	receiveCommand(t, ws, "server_params")
+
+	websocket.JSON.Send(ws, &Request{
+		Command:    "scope_of_view",
+		Position:   vec2d.New(100, 100),
+		Resolution: []uint64{1000, 1000},
+	})
+	scope := receiveCommand(t, ws, "scope_of_view_result")
+	planets := scope["Planets"].(map[string]interface{})
+	packet, ok := planets[planet.Key()].(map[string]interface{})
+	if !ok {
+		t.Fatalf("The spectator does not see %s in %v", planet.Key(), planets)
+	}
+	if packet["ShipCount"].(float64) != 42 {
+		t.Errorf("The spectator sees %v ships", packet["ShipCount"])
+	}
+
+	planet.ShipCount = 24
+	entities.Save(planet)
+	clients.Broadcast(planet)
+	change := receiveCommand(t, ws, "state_change")
+	packet = change["Planets"].(map[string]interface{})[planet.Key()].(map[string]interface{})
+	if packet["ShipCount"].(float64) != 24 {
+		t.Errorf("The state change shows %v ships to the spectator", packet["ShipCount"])
+	}
+
+	websocket.JSON.Send(ws, &Request{
+		Command:      "start_mission",
+		StartPlanets: []string{planet.Key()},
+		EndPlanet:    "planet.GOP6720",
+		Type:         "Attack",
+	})
+	if message := receiveCommand(t, ws, "error"); message["Message"] != "Spectators could only change their scope of view" {
+		t.Errorf("The spectator issued a game command: %v", message)
+	}
+
+	for username := range clients.pool {
+		t.Errorf("%s is online while only a spectator is connected", username)
+	}
+}
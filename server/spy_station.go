@@ -0,0 +1,43 @@
+package server
+
+import "sync"
+
+// Keeps a recall channel for every spy station, so the player could call
+// the spies back home while the missionary waits for the next report.
+type stationPool struct {
+	mutex   sync.Mutex
+	recalls map[string]chan struct{}
+}
+
+var spyStations = stationPool{recalls: make(map[string]chan struct{})}
+
+// Registers a station and returns the channel closed on recall.
+func (s *stationPool) add(missionKey string) <-chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	recall := make(chan struct{})
+	s.recalls[missionKey] = recall
+	return recall
+}
+
+func (s *stationPool) remove(missionKey string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.recalls, missionKey)
+}
+
+// Recalls the spies of the given mission. Returns false if there is no
+// such station (yet).
+func (s *stationPool) recall(missionKey string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	recall, ok := s.recalls[missionKey]
+	if ok {
+		close(recall)
+		delete(s.recalls, missionKey)
+	}
+	return ok
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/clock"
+	"warcluster/entities"
+)
+
+func TestStationPool(t *testing.T) {
+	recall := spyStations.add("mission.1_GOP6720")
+
+	if !spyStations.recall("mission.1_GOP6720") {
+		t.Error("Registered station could not be recalled")
+	}
+	select {
+	case <-recall:
+	default:
+		t.Error("The station is not notified about the recall")
+	}
+
+	if spyStations.recall("mission.1_GOP6720") {
+		t.Error("The station is recalled twice")
+	}
+}
+
+// Sets the chance of every owner to detect spies for the rest of the test.
+func setSpyDetection(chance float64) func() {
+	old := *entities.Settings()
+	settings := old
+	settings.SpyDetectionChance = chance
+	settings.SpyDetectionPerShip = 0
+	settings.SpyDetectionMaxChance = chance
+	entities.ReloadSettings(settings)
+	return func() { entities.ReloadSettings(old) }
+}
+
+func TestRecallStationedSpies(t *testing.T) {
+	defer setSpyDetection(0)()
+
+	target := &entities.Planet{
+		Name:                "PAN6721",
+		Position:            vec2d.New(10, 10),
+		Owner:               "panda",
+		ShipCount:           10,
+		LastShipCountUpdate: time.Now().Unix(),
+	}
+	entities.Save(target)
+
+	spies := &entities.Mission{
+		Type:      "Spy",
+		Player:    "gophie",
+		ShipCount: 5,
+		StartTime: time.Now().UnixNano() / 1e6,
+	}
+	spies.Source.Name = "GOP6720"
+	spies.Target.Name = target.Name
+	spies.Target.Owner = target.Owner
+	spies.Station(1000, 0)
+
+	survivors := make(chan int32)
+	go func() {
+		_, ships := defaultUniverse.stationSpies(clock.Current(), spies, target, target.Key(), nil)
+		survivors <- ships
+	}()
+
+	for !spyStations.recall(spies.Key()) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case ships := <-survivors:
+		if ships != 4 {
+			t.Errorf("%d spies returned instead of 4", ships)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("The spies did not return after being recalled")
+	}
+}
+
+func TestDetectStationedSpies(t *testing.T) {
+	defer setSpyDetection(1)()
+
+	target := &entities.Planet{
+		Name:                "PAN6721",
+		Position:            vec2d.New(10, 10),
+		Owner:               "panda",
+		ShipCount:           10,
+		LastShipCountUpdate: time.Now().Unix(),
+	}
+	entities.Save(target)
+
+	spies := &entities.Mission{
+		Type:      "Spy",
+		Player:    "gophie",
+		ShipCount: 1,
+		StartTime: time.Now().UnixNano() / 1e6,
+	}
+	spies.Source.Name = "GOP6720"
+	spies.Target.Name = target.Name
+	spies.Target.Owner = target.Owner
+	spies.Station(1000, 0)
+
+	if _, ships := defaultUniverse.stationSpies(clock.Current(), spies, target, target.Key(), nil); ships != 0 {
+		t.Errorf("%d detected spies survived", ships)
+	}
+
+	caught := entities.PlayerHistory("gophie")
+	if len(caught) == 0 || caught[0].Event != entities.SpiesCaught || caught[0].Other != "panda" {
+		t.Errorf("Caught spies are not in the history of the spy: %#v", caught)
+	}
+	detected := entities.PlayerHistory("panda")
+	if len(detected) == 0 || detected[0].Event != entities.SpiesDetected || detected[0].Ships != 1 {
+		t.Errorf("Detected spies are not in the history of the owner: %#v", detected)
+	}
+}
+
+func TestRecalledSpiesLeaveTheTarget(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.May, 25, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.SpyDetectionChance = 0
+	settings.SpyDetectionMaxChance = 0
+	settings.SpyEnergy = 0
+	u := newTestUniverse(t, settings)
+	world := u.World()
+	spy := registerTestPlayer(u, "spy")
+	owner := registerTestPlayer(u, "owner")
+
+	client := testClient(u, spy)
+	entity, _ := world.Get(owner.HomePlanet)
+	spies, err := prepareMission(spy.HomePlanet, entity.(*entities.Planet), &Request{
+		Client:       client,
+		StartPlanets: []string{spy.HomePlanet},
+		EndPlanet:    owner.HomePlanet,
+		Type:         "Spy",
+		Fleet:        10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for len(world.Find("spy_report.*")) == 0 {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		fake.Step()
+	}
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The planet changes while the spies wait for their next report
+	entity, _ = world.Get(owner.HomePlanet)
+	target := entity.(*entities.Planet)
+	target.SetShipCount(12345)
+	world.Save(target)
+
+	if !spyStations.recall(spies.Key()) {
+		t.Fatal("The spies are not stationed")
+	}
+	for {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := world.Get(spies.Key()); err != nil {
+			break
+		}
+		fake.Step()
+	}
+
+	entity, _ = world.Get(owner.HomePlanet)
+	if ships := entity.(*entities.Planet).ShipCount; ships != 12345 {
+		t.Errorf("The spies have written back the planet with %d ships", ships)
+	}
+
+	if err := fake.WaitIdle(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	missions := world.Find("mission.*")
+	if len(missions) != 1 {
+		t.Fatalf("%d missions are flying instead of the spies going back", len(missions))
+	}
+	if back := missions[0].(*entities.Mission); back.Type != "Supply" || back.Target.Owner != spy.Username {
+		t.Errorf("The spies go back as %s to a planet of %q", back.Type, back.Target.Owner)
+	}
+}
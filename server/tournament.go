@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/server/response"
+)
+
+// States of a tournament.
+const (
+	tournamentPending  = "pending"
+	tournamentRunning  = "running"
+	tournamentFinished = "finished"
+)
+
+// Tournament is a game played in a universe for a limited time. Players
+// could change the game only while it's running, the rest of the time the
+// universe is frozen. Once it's over the result is saved in the world of
+// the universe.
+type Tournament struct {
+	Start        time.Time
+	Duration     time.Duration
+	WinCondition string
+
+	mutex  sync.Mutex
+	result *entities.TournamentResult
+}
+
+// Creates a tournament starting at the given time and won by the given
+// condition, one of config.WinConditions.
+func NewTournament(start time.Time, duration time.Duration, winCondition string) *Tournament {
+	return &Tournament{
+		Start:        start,
+		Duration:     duration,
+		WinCondition: winCondition,
+	}
+}
+
+// Returns when the tournament is over, unless it's won earlier.
+func (t *Tournament) End() time.Time {
+	return t.Start.Add(t.Duration)
+}
+
+// Returns the state of the tournament at the given time.
+func (t *Tournament) state(now time.Time) string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case t.result != nil || !now.Before(t.End()):
+		return tournamentFinished
+	case now.Before(t.Start):
+		return tournamentPending
+	}
+	return tournamentRunning
+}
+
+// Returns the result of the tournament or nil if it isn't over yet.
+func (t *Tournament) Result() *entities.TournamentResult {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.result
+}
+
+// Hosts the tournament in the universe. It has to be done before the
+// universe is started.
+func (u *Universe) HostTournament(t *Tournament) {
+	u.tournament = t
+}
+
+// Returns the tournament hosted in the universe, nil if there is none.
+func (u *Universe) Tournament() *Tournament {
+	if u == nil {
+		return nil
+	}
+	return u.tournament
+}
+
+// Waits for the start and the end of the tournament and lets everyone in
+// the universe know about them. A tournament which has been finished
+// before the server was restarted is not played again.
+func (u *Universe) runTournament(c clock.Clock) {
+	t := u.tournament
+	for _, result := range u.world.TournamentResults() {
+		if result.Start == t.Start.UnixNano()/1e6 {
+			t.mutex.Lock()
+			t.result = result
+			t.mutex.Unlock()
+			return
+		}
+	}
+
+	if wait := t.Start.Sub(c.Now()); wait > 0 {
+		clock.SleepOn(c, wait)
+		log.Printf("Universe %s: the tournament has started", u.ID)
+		u.Clients().SendAll(u.tournamentStatus(c.Now()))
+	}
+	if wait := t.End().Sub(c.Now()); wait > 0 {
+		clock.SleepOn(c, wait)
+	}
+	u.finishTournament(c.Now())
+}
+
+// Ends the tournament at the given time, saves its result and sends it to
+// everyone in the universe. Does nothing if it's over already.
+func (u *Universe) finishTournament(now time.Time) {
+	t := u.tournament
+	t.mutex.Lock()
+	if t.result != nil {
+		t.mutex.Unlock()
+		return
+	}
+	if now.After(t.End()) {
+		now = t.End()
+	}
+	t.result = u.world.TournamentResult(t.Start, now, t.WinCondition)
+	u.world.Save(t.result)
+	t.mutex.Unlock()
+
+	log.Printf("Universe %s: the tournament is over, the winner is %q", u.ID, t.result.Winner)
+	u.Clients().SendAll(u.tournamentStatus(now))
+}
+
+// Called once a home planet changes its owner. A last home standing
+// tournament is over as soon as a single player owns their home planet.
+func (u *Universe) homePlanetCaptured() {
+	t := u.Tournament()
+	if t == nil || t.WinCondition != config.WinLastHomeStanding {
+		return
+	}
+	now := clock.Now()
+	if t.state(now) != tournamentRunning {
+		return
+	}
+
+	standing := 0
+	for _, player := range u.world.TournamentResult(t.Start, now, t.WinCondition).Players {
+		if player.HomePlanet {
+			standing++
+		}
+	}
+	if standing <= 1 {
+		u.finishTournament(now)
+	}
+}
+
+// Returns an error if the tournament hosted in the universe is not
+// running, so the players could not change the game.
+func (u *Universe) checkTournament() error {
+	t := u.Tournament()
+	if t == nil {
+		return nil
+	}
+	switch t.state(clock.Now()) {
+	case tournamentPending:
+		return errors.New("The tournament hasn't started yet.")
+	case tournamentFinished:
+		return errors.New("The tournament is over.")
+	}
+	return nil
+}
+
+// Returns the tournament_status message of the universe at the given
+// time, nil if it hosts no tournament.
+func (u *Universe) tournamentStatus(now time.Time) *response.TournamentStatus {
+	t := u.Tournament()
+	if t == nil {
+		return nil
+	}
+	status := response.NewTournamentStatus(t.state(now), t.Start, t.End(), t.WinCondition)
+	if result := t.Result(); result != nil {
+		status.End = result.End
+		status.Winner = result.Winner
+	}
+	return status
+}
+
+// Returns the result of the tournament in the universe given with the
+// `universe` query parameter. There is nothing until it is over.
+func tournamentResultsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	u, err := universeOfRequest(r)
+	if err != nil || u.Tournament() == nil {
+		http.Error(w, "Tournament Not Found", 404)
+		return
+	}
+
+	result := u.Tournament().Result()
+	if result == nil {
+		http.Error(w, "Tournament Is Not Over", 404)
+		return
+	}
+
+	serialized, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	fmt.Fprint(w, string(serialized))
+}
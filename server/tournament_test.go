@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/entities/db"
+	"warcluster/leaderboard"
+)
+
+// Waits for a timer expiring at the given time and fires it.
+func stepTo(t *testing.T, fake *clock.Fake, deadline time.Time) {
+	timeout := time.Now().Add(time.Second)
+	for next, _ := fake.Next(); !next.Equal(deadline); next, _ = fake.Next() {
+		if time.Now().After(timeout) {
+			t.Fatalf("Nobody waits for %s, the next timer expires at %s", deadline, next)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	fake.Step()
+}
+
+func TestTournament(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.June, 1, 17, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.PirateFactions = 0
+	settings.PirateRaidInterval = 24 * 3600
+	u, err := NewUniverse("cup", db.NewMemoryPool(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := fake.Now().Add(time.Hour)
+	u.HostTournament(NewTournament(start, 2*time.Hour, config.WinMostPlanets))
+	if err := u.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer u.Stop()
+
+	ws := dialUniverse(t, "/universe/cup")
+	defer ws.Close()
+	websocket.JSON.Send(ws, &Request{Command: "login", Username: "contender", TwitterID: "contender"})
+	receiveCommand(t, ws, "request_setup_params")
+	websocket.JSON.Send(ws, &setupParams)
+	login := receiveCommand(t, ws, "login_success")
+	if status := receiveCommand(t, ws, "tournament_status"); status["State"] != tournamentPending {
+		t.Errorf("The tournament is %v before the start", status["State"])
+	}
+
+	home := login["HomePlanet"].(map[string]interface{})["Name"].(string)
+	upgrade := &Request{Command: "upgrade_planet", Planet: "planet." + home, Building: config.Shipyard}
+	websocket.JSON.Send(ws, upgrade)
+	if message := receiveCommand(t, ws, "error")["Message"]; message != "The tournament hasn't started yet." {
+		t.Errorf("A planet is upgraded before the start: %v", message)
+	}
+
+	stepTo(t, fake, start)
+	if status := receiveCommand(t, ws, "tournament_status"); status["State"] != tournamentRunning {
+		t.Errorf("The tournament is %v after the start", status["State"])
+	}
+
+	results, _ := http.NewRequest("GET", "/tournament/results?universe=cup", nil)
+	response := httptest.NewRecorder()
+	tournamentResultsHandler(response, results)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("There are results of a running tournament: %d", response.Code)
+	}
+
+	stepTo(t, fake, start.Add(2*time.Hour))
+	status := receiveCommand(t, ws, "tournament_status")
+	if status["State"] != tournamentFinished || status["Winner"] != "contender" {
+		t.Errorf("The tournament is %v and won by %v", status["State"], status["Winner"])
+	}
+
+	websocket.JSON.Send(ws, upgrade)
+	if message := receiveCommand(t, ws, "error")["Message"]; message != "The tournament is over." {
+		t.Errorf("A planet is upgraded after the end: %v", message)
+	}
+
+	response = httptest.NewRecorder()
+	tournamentResultsHandler(response, results)
+	var result entities.TournamentResult
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Winner != "contender" || len(result.Players) != 1 || result.Players[0].Planets != 1 {
+		t.Errorf("Wrong results: %#v", result)
+	}
+	if result.End != start.Add(2*time.Hour).UnixNano()/1e6 {
+		t.Errorf("The tournament ended at %d", result.End)
+	}
+	if saved := u.World().TournamentResults(); len(saved) != 1 || saved[0].Winner != "contender" {
+		t.Errorf("The results are not saved: %v", saved)
+	}
+}
+
+func TestTournamentResult(t *testing.T) {
+	u, err := NewUniverse("standings", db.NewMemoryPool(), *entities.Settings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.initLeaderboard(leaderboard.New())
+	world := u.World()
+	players := make(map[string]*entities.Player)
+	for _, name := range []string{"first", "second", "third"} {
+		setupData := &entities.SetupData{Race: 0}
+		if name == "third" {
+			setupData.Race = 1
+		}
+		players[name] = u.register(setupData, name, name, nil)
+	}
+
+	conquer := func(owner string, count int) {
+		for _, entity := range world.Find("planet.*") {
+			planet := entity.(*entities.Planet)
+			if count > 0 && planet.Owner == "" {
+				planet.Owner = owner
+				world.Save(planet)
+				count--
+			}
+		}
+	}
+	conquer("first", 2)
+	conquer("third", 1)
+
+	now := clock.Now()
+	result := world.TournamentResult(now, now, config.WinMostPlanets)
+	if result.Winner != "first" || result.Players[2].Name != "second" {
+		t.Errorf("The standings by planets are %#v", result.Players)
+	}
+	result = world.TournamentResult(now, now, config.WinMostRacePlanets)
+	if result.Winner != entities.Races[0].Name || result.Races[0].Planets != 4 {
+		t.Errorf("The standings by race are %#v", result.Races)
+	}
+
+	capture := func(key, owner string) {
+		entity, _ := world.Get(key)
+		planet := entity.(*entities.Planet)
+		planet.Owner = owner
+		world.Save(planet)
+	}
+	capture(players["second"].HomePlanet, "third")
+	result = world.TournamentResult(now, now, config.WinLastHomeStanding)
+	if result.Winner != "" || result.Players[2].Name != "second" || result.Players[2].HomePlanet {
+		t.Errorf("The even players are %#v", result.Players)
+	}
+	capture(players["first"].HomePlanet, "third")
+	result = world.TournamentResult(now, now, config.WinLastHomeStanding)
+	if result.Winner != "third" {
+		t.Errorf("The last home standing is %q", result.Winner)
+	}
+}
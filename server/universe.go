@@ -0,0 +1,200 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/garyburd/redigo/redis"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/leaderboard"
+	"warcluster/replay"
+	"warcluster/server/response"
+)
+
+// Universe is a world hosted by the server along with everything needed to
+// play in it: the clients online, the missionaries of its missions, its
+// leaderboard and the log of its game. Nothing is shared between two
+// universes, so a player could have an empire in each of them.
+//
+// The nil *Universe is the default one, served on /universe. It is stored
+// in the default world and uses the client pool, the missionaries, the
+// leaderboard and the recorder of the package. All others are served on
+// /universe/<id>.
+type Universe struct {
+	ID           string
+	world        *entities.World
+	clients      *ClientPool
+	leaderBoard  *leaderboard.Leaderboard
+	recorder     *replay.Recorder
+	tournament   *Tournament
+	missionaries *missionaryPool
+}
+
+// The universe served on /universe.
+var defaultUniverse *Universe
+
+// All universes but the default one, keyed by their id.
+var universes = struct {
+	sync.RWMutex
+	byID map[string]*Universe
+}{byID: make(map[string]*Universe)}
+
+// Creates a universe stored in the given database and played by the given
+// settings. It is not served until it is started.
+func NewUniverse(id string, pool *redis.Pool, settings config.Entities) (*Universe, error) {
+	world, err := entities.NewWorld(pool, settings)
+	if err != nil {
+		return nil, fmt.Errorf("Universe %s: %s", id, err)
+	}
+
+	u := &Universe{
+		ID:           id,
+		world:        world,
+		clients:      NewClientPool(13),
+		missionaries: new(missionaryPool),
+	}
+	u.clients.world = world
+	return u, nil
+}
+
+// Loads the leaderboard of the universe, spawns the missionaries of the
+// missions on their way and starts serving the universe on
+// /universe/<id>.
+func (u *Universe) Start() error {
+	universes.Lock()
+	defer universes.Unlock()
+
+	if _, ok := universes.byID[u.ID]; ok {
+		return fmt.Errorf("Universe %s is already started", u.ID)
+	}
+	u.initLeaderboard(leaderboard.New())
+	u.spawnDbMissions()
+	clock.Go(u.startPirates)
+	if u.tournament != nil {
+		clock.Go(u.runTournament)
+	}
+	universes.byID[u.ID] = u
+	log.Printf("Universe %s has started", u.ID)
+	return nil
+}
+
+// Stops serving the universe. Players online stay connected until they
+// leave and missions on their way are finished.
+func (u *Universe) Stop() {
+	universes.Lock()
+	delete(universes.byID, u.ID)
+	universes.Unlock()
+	u.StopRecording()
+}
+
+// Returns the universe with the given id or the default one for "".
+func findUniverse(id string) (*Universe, error) {
+	if id == "" {
+		return defaultUniverse, nil
+	}
+
+	universes.RLock()
+	defer universes.RUnlock()
+	if u, ok := universes.byID[id]; ok {
+		return u, nil
+	}
+	return nil, fmt.Errorf("Universe %s does not exist", id)
+}
+
+// Returns the universe of a websocket by its path: /universe/<id> or
+// /universe for the default one.
+func universeOfPath(path string) (*Universe, error) {
+	id := strings.Trim(strings.TrimPrefix(path, "/universe"), "/")
+	if strings.Contains(id, "/") {
+		return nil, errors.New("Invalid universe")
+	}
+	return findUniverse(id)
+}
+
+// Returns the universe given with the `universe` query parameter of the
+// request, the default one if there is none.
+func universeOfRequest(r *http.Request) (*Universe, error) {
+	return findUniverse(r.URL.Query().Get("universe"))
+}
+
+// Returns all universes but the default one.
+func startedUniverses() []*Universe {
+	universes.RLock()
+	defer universes.RUnlock()
+
+	result := make([]*Universe, 0, len(universes.byID))
+	for _, u := range universes.byID {
+		result = append(result, u)
+	}
+	return result
+}
+
+// Returns the world of the universe.
+func (u *Universe) World() *entities.World {
+	if u == nil {
+		return nil
+	}
+	return u.world
+}
+
+// Returns the pool of the clients playing in the universe.
+func (u *Universe) Clients() *ClientPool {
+	if u == nil {
+		return clients
+	}
+	return u.clients
+}
+
+// Returns the missionaries of the missions on their way in the universe.
+func (u *Universe) Missionaries() *missionaryPool {
+	if u == nil {
+		return missionaries
+	}
+	return u.missionaries
+}
+
+// Returns the leaderboard of the universe.
+func (u *Universe) Leaderboard() *leaderboard.Leaderboard {
+	if u == nil {
+		return leaderBoard
+	}
+	return u.leaderBoard
+}
+
+// Returns the settings of the universe in the given config. Nothing is
+// changed yet, but it is an error if the universe could not switch to
+// them at runtime.
+func (u *Universe) settingsIn(newCfg config.Config) (config.Entities, error) {
+	if u == nil {
+		return newCfg.Entities, u.World().CheckSettings(newCfg.Entities)
+	}
+
+	settings, err := newCfg.UniverseEntities(u.ID)
+	if err != nil {
+		return settings, err
+	}
+	if err := u.world.CheckSettings(settings); err != nil {
+		return settings, fmt.Errorf("Universe %s: %s", u.ID, err)
+	}
+	return settings, nil
+}
+
+// Switches the universe to the given settings, which are already checked
+// by settingsIn, and sends the new server params to everyone in it.
+func (u *Universe) applySettings(settings config.Entities) {
+	if err := u.World().ReloadSettings(settings); err != nil {
+		log.Println(err)
+		return
+	}
+
+	if pool := u.Clients(); pool != nil {
+		pool.SendAll(response.NewServerParams(u.World()))
+	}
+}
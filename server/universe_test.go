@@ -0,0 +1,97 @@
+package server
+
+import (
+	"testing"
+
+	"golang.org/x/net/websocket"
+
+	"warcluster/entities"
+	"warcluster/entities/db"
+)
+
+func dialUniverse(t *testing.T, path string) *websocket.Conn {
+	ws, err := websocket.Dial("ws://localhost:7013"+path, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestUniverse(t *testing.T) {
+	settings := *entities.Settings()
+	settings.MissionSpeed = 42
+	u, err := NewUniverse("private", db.NewMemoryPool(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer u.Stop()
+	if err := u.Start(); err == nil {
+		t.Error("The universe has been started twice")
+	}
+
+	ws := dialUniverse(t, "/universe/missing")
+	receiveCommand(t, ws, "error")
+	ws.Close()
+
+	ws = dialUniverse(t, "/universe/private")
+	defer ws.Close()
+	websocket.JSON.Send(ws, &Request{Command: "login", Username: "universal", TwitterID: "universal"})
+	params := receiveCommand(t, ws, "server_params")
+	if speed := params["SpeedModel"].(map[string]interface{})["DefaultSpeed"]; speed != float64(42) {
+		t.Errorf("The universe is played with mission speed %v", speed)
+	}
+	receiveCommand(t, ws, "request_setup_params")
+	websocket.JSON.Send(ws, &setupParams)
+	receiveCommand(t, ws, "login_success")
+
+	entity, err := u.World().Get("player.universal")
+	if err != nil {
+		t.Fatalf("The player is not in the universe: %s", err)
+	}
+	if _, err := entities.Get("player.universal"); err == nil {
+		t.Error("The player is in the default universe as well")
+	}
+	if u.Leaderboard().Place("universal") != 0 || u.Leaderboard().Len() != 1 {
+		t.Errorf("The leaderboard of the universe has %d players", u.Leaderboard().Len())
+	}
+	if leaderBoard != nil && leaderBoard.Place("universal") != 0 {
+		t.Error("The player is in the leaderboard of the default universe")
+	}
+
+	player := entity.(*entities.Player)
+	home, err := u.World().Get(player.HomePlanet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	websocket.JSON.Send(ws, &Request{
+		Command:    "scope_of_view",
+		Position:   home.(*entities.Planet).Position,
+		Resolution: []uint64{1000, 1000},
+	})
+	scope := receiveCommand(t, ws, "scope_of_view_result")
+	if _, ok := scope["Planets"].(map[string]interface{})[player.HomePlanet]; !ok {
+		t.Errorf("The player does not see the home planet")
+	}
+	if _, err := u.Clients().Player("universal"); err != nil {
+		t.Error("The player is not online in the universe")
+	}
+	if _, err := clients.Player("universal"); err == nil {
+		t.Error("The player is online in the default universe")
+	}
+}
+
+func TestUniverseOfPath(t *testing.T) {
+	for _, path := range []string{"/universe", "/universe/"} {
+		if u, err := universeOfPath(path); u != nil || err != nil {
+			t.Errorf("%s is not the default universe: %v, %v", path, u, err)
+		}
+	}
+	for _, path := range []string{"/universe/missing", "/universe/a/b"} {
+		if _, err := universeOfPath(path); err == nil {
+			t.Errorf("%s has been found", path)
+		}
+	}
+}
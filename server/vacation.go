@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+
+	"warcluster/entities"
+	"warcluster/server/response"
+)
+
+// Sends the player on vacation. Everyone sees their planets stop. Nobody
+// could go on vacation in a universe hosting a tournament, as their
+// planets could not be taken in the meantime.
+func startVacation(request *Request) error {
+	u := request.Client.universe
+	if u.Tournament() != nil {
+		return errors.New("There are no vacations during a tournament.")
+	}
+	return changeVacation(request, u.World().StartVacation)
+}
+
+// Brings the player back from vacation, once it has lasted long enough.
+func endVacation(request *Request) error {
+	u := request.Client.universe
+	if err := u.checkTournament(); err != nil {
+		return err
+	}
+	return changeVacation(request, u.World().EndVacation)
+}
+
+func changeVacation(request *Request, change func(*entities.Player) ([]*entities.Planet, error)) error {
+	u := request.Client.universe
+	planets, err := change(request.Client.Player)
+	if err != nil {
+		return err
+	}
+
+	for _, planet := range planets {
+		u.Clients().Broadcast(planet)
+	}
+	request.Client.Send(response.NewVacation(u.World(), request.Client.Player))
+	return nil
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/config"
+	"warcluster/entities"
+)
+
+func TestVacation(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 3, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.NewbieProtection = 0
+	u := newTestUniverse(t, settings)
+	world := u.World()
+
+	traveller := registerTestPlayer(u, "traveller")
+	raider := registerTestPlayer(u, "raider")
+	request := func(player *entities.Player, command string) *Request {
+		return &Request{
+			Client:       testClient(u, player),
+			Command:      command,
+			StartPlanets: []string{player.HomePlanet},
+			Type:         "Attack",
+			Fleet:        100,
+		}
+	}
+	attack := func(attacker, target *entities.Player) (*entities.Mission, error) {
+		entity, _ := world.Get(target.HomePlanet)
+		attack := request(attacker, "start_mission")
+		attack.EndPlanet = target.HomePlanet
+		return prepareMission(attacker.HomePlanet, entity.(*entities.Planet), attack)
+	}
+	home := func() *entities.Planet {
+		entity, _ := world.Get(traveller.HomePlanet)
+		planet := entity.(*entities.Planet)
+		planet.UpdateShipCount()
+		return planet
+	}
+
+	raid, err := attack(raider, traveller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := startVacation(request(traveller, "start_vacation")); err != nil {
+		t.Fatal(err)
+	}
+	ships := home().ShipCount
+	if !home().OnVacation {
+		t.Error("The planets of the player on vacation are not marked")
+	}
+	if _, err := attack(raider, traveller); err == nil || err.Error() != "The owner of the planet is on vacation." {
+		t.Errorf("The player on vacation is attacked: %v", err)
+	}
+	if _, err := attack(traveller, raider); err == nil || err.Error() != "The player is on vacation." {
+		t.Errorf("The player on vacation sends missions: %v", err)
+	}
+
+	for {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := world.Get(raid.Key()); err != nil {
+			break
+		}
+		fake.Step()
+	}
+	if planet := home(); planet.ShipCount != ships || planet.Owner != traveller.Username {
+		t.Errorf("The raid has left %d of %d ships to %s", planet.ShipCount, ships, planet.Owner)
+	}
+
+	if err := endVacation(request(traveller, "end_vacation")); err == nil || !strings.HasPrefix(err.Error(), "The vacation could end in") {
+		t.Errorf("The vacation has ended too early: %v", err)
+	}
+	fake.Advance(settings.VacationMinDuration * time.Second)
+	if planet := home(); planet.ShipCount != ships {
+		t.Errorf("The planet has produced %d ships on vacation", planet.ShipCount-ships)
+	}
+	if err := endVacation(request(traveller, "end_vacation")); err != nil {
+		t.Fatal(err)
+	}
+	if planet := home(); planet.OnVacation || planet.LastShipCountUpdate != fake.Now().Unix() {
+		t.Error("The planet is still on vacation")
+	}
+	if err := startVacation(request(traveller, "start_vacation")); err == nil || !strings.HasPrefix(err.Error(), "The next vacation could start in") {
+		t.Errorf("The next vacation has started right away: %v", err)
+	}
+}
+
+func TestVacationInTournament(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 4, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.VacationMinDuration = 0
+	u := newTestUniverse(t, settings)
+	u.HostTournament(NewTournament(fake.Now().Add(time.Hour), time.Hour, config.WinLastHomeStanding))
+
+	player := registerTestPlayer(u, "contender")
+	client := testClient(u, player)
+
+	if err := startVacation(&Request{Client: client}); err == nil {
+		t.Error("The player has gone on vacation in a tournament")
+	}
+
+	u.World().StartVacation(player)
+	if err := endVacation(&Request{Client: client}); err == nil || err.Error() != "The tournament hasn't started yet." {
+		t.Errorf("The vacation has ended before the tournament: %v", err)
+	}
+	fake.Advance(time.Hour)
+	if err := endVacation(&Request{Client: client}); err != nil {
+		t.Errorf("The vacation could not end during the tournament: %s", err)
+	}
+}
+
+func TestSupplyOfPlanetTakenOnVacation(t *testing.T) {
+	fake := clock.NewFake(time.Date(2015, time.July, 5, 18, 0, 0, 0, time.UTC))
+	defer clock.Set(clock.Set(fake))
+
+	settings := *entities.Settings()
+	settings.NewbieProtection = 0
+	u := newTestUniverse(t, settings)
+	world := u.World()
+
+	traveller := registerTestPlayer(u, "traveller")
+	supplier := registerTestPlayer(u, "supplier")
+	var outpost *entities.Planet
+	for _, entity := range world.Find("planet.*") {
+		if planet := entity.(*entities.Planet); !planet.HasOwner() {
+			outpost = planet
+		}
+	}
+	outpost.Owner = supplier.Username
+	world.Save(outpost)
+
+	supply, err := prepareMission(supplier.HomePlanet, outpost, &Request{
+		Client:       testClient(u, supplier),
+		StartPlanets: []string{supplier.HomePlanet},
+		EndPlanet:    outpost.Key(),
+		Type:         "Supply",
+		Fleet:        100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The outpost is taken while the supply is on its way
+	outpost.Owner = traveller.Username
+	world.Save(outpost)
+	if _, err := world.StartVacation(traveller); err != nil {
+		t.Fatal(err)
+	}
+	entity, _ := world.Get(outpost.Key())
+	ships := entity.(*entities.Planet).ShipCount
+
+	for {
+		if err := fake.WaitIdle(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := world.Get(supply.Key()); err != nil {
+			break
+		}
+		fake.Step()
+	}
+	entity, _ = world.Get(outpost.Key())
+	if planet := entity.(*entities.Planet); planet.ShipCount != ships || planet.Owner != traveller.Username {
+		t.Errorf("The supply has left %d of %d ships to %s", planet.ShipCount, ships, planet.Owner)
+	}
+}
@@ -0,0 +1,18 @@
+package snapshot
+
+import (
+	"log"
+
+	"warcluster/config"
+	"warcluster/entities"
+	"warcluster/entities/db"
+)
+
+func init() {
+	var cfg config.Config
+	if err := cfg.Load(); err != nil {
+		log.Fatal(err)
+	}
+	entities.ExportConfig(cfg)
+	db.InitPool(cfg.Database.Host, cfg.Database.Port, 13)
+}
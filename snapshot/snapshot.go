@@ -0,0 +1,200 @@
+// Package snapshot dumps the whole universe into a versioned JSON document
+// and restores it back into the database.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"warcluster/clock"
+	"warcluster/entities"
+)
+
+// Version of the snapshot format. Bump it whenever the layout of
+// Snapshot changes in a way older readers can't handle.
+const Version = 1
+
+// Snapshot holds every persistent entity of the universe.
+// CreatedAt is in ms, just like Mission.StartTime.
+type Snapshot struct {
+	Version    int
+	CreatedAt  int64
+	Players    []*entities.Player
+	Suns       []*entities.Sun
+	Planets    []*entities.Planet
+	SolarSlots []*entities.SolarSlot
+	Missions   []*entities.Mission
+	SpyReports []*entities.SpyReport
+	History    []*entities.HistoryEntry
+}
+
+// Take collects all entities from the database into a new snapshot.
+// Records are sorted by key so two snapshots of the same world are
+// byte for byte identical.
+func Take() *Snapshot {
+	return TakeFrom(nil)
+}
+
+// TakeFrom collects all entities of the given world just like Take does.
+func TakeFrom(w *entities.World) *Snapshot {
+	s := &Snapshot{
+		Version:   Version,
+		CreatedAt: clock.NowMs(),
+	}
+
+	for _, entity := range find(w, "player.*") {
+		s.Players = append(s.Players, entity.(*entities.Player))
+	}
+	for _, entity := range find(w, "sun.*") {
+		s.Suns = append(s.Suns, entity.(*entities.Sun))
+	}
+	for _, entity := range find(w, "planet.*") {
+		s.Planets = append(s.Planets, entity.(*entities.Planet))
+	}
+	for _, entity := range find(w, "ss.*") {
+		s.SolarSlots = append(s.SolarSlots, entity.(*entities.SolarSlot))
+	}
+	for _, entity := range find(w, "mission.*") {
+		s.Missions = append(s.Missions, entity.(*entities.Mission))
+	}
+	for _, entity := range find(w, "spy_report.*") {
+		s.SpyReports = append(s.SpyReports, entity.(*entities.SpyReport))
+	}
+	for _, entity := range find(w, "history.*") {
+		s.History = append(s.History, entity.(*entities.HistoryEntry))
+	}
+	return s
+}
+
+// Patterns of all records a snapshot holds.
+var patterns = []string{"player.*", "sun.*", "planet.*", "ss.*", "mission.*", "spy_report.*", "history.*"}
+
+// Restore writes all entities of the snapshot into the database.
+// All timestamps are shifted by the time passed since the snapshot was
+// taken, so missions continue from the point they were exported at instead
+// of arriving all at once. The database must not hold a universe already,
+// as the two would be mixed up, so it has to be flushed beforehand. The
+// leaderboard is not stored, it is rebuilt out of the restored records.
+func (s *Snapshot) Restore(now time.Time) error {
+	if s.Version != Version {
+		return fmt.Errorf("Unsupported snapshot version %d (expected %d)", s.Version, Version)
+	}
+	for _, pattern := range patterns {
+		if keys, _ := entities.GetList(pattern); len(keys) > 0 {
+			return fmt.Errorf("The database is not empty (%s is there), flush it before restoring", keys[0])
+		}
+	}
+	s.reanchor(now.UnixNano() / 1e6)
+
+	// Order matters: players need their home planet in order to
+	// find their area set and missions need their source planet.
+	planets := make(map[string]*entities.Planet, len(s.Planets))
+	for _, slot := range s.SolarSlots {
+		if err := entities.Save(slot); err != nil {
+			return err
+		}
+	}
+	for _, sun := range s.Suns {
+		if err := entities.Save(sun); err != nil {
+			return err
+		}
+	}
+	for _, planet := range s.Planets {
+		planets[planet.Name] = planet
+		if err := entities.Save(planet); err != nil {
+			return err
+		}
+	}
+	for _, player := range s.Players {
+		if _, ok := planets[strings.TrimPrefix(player.HomePlanet, "planet.")]; !ok {
+			return fmt.Errorf("Home planet %s of %s is missing", player.HomePlanet, player.Username)
+		}
+		if err := entities.Save(player); err != nil {
+			return err
+		}
+	}
+	for _, report := range s.SpyReports {
+		if err := entities.Save(report); err != nil {
+			return err
+		}
+	}
+	for _, entry := range s.History {
+		if err := entities.Save(entry); err != nil {
+			return err
+		}
+	}
+	for _, mission := range s.Missions {
+		source, ok := planets[mission.Source.Name]
+		if !ok {
+			return fmt.Errorf("Source planet %s of %s is missing", mission.Source.Name, mission.Key())
+		}
+		mission.SetAreaSet(source.AreaSet())
+		if err := entities.Save(mission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Moves all timestamps in the snapshot as if it was taken at `now` (in ms).
+func (s *Snapshot) reanchor(now int64) {
+	offset := now - s.CreatedAt
+	for _, mission := range s.Missions {
+		mission.StartTime += offset
+	}
+	for _, planet := range s.Planets {
+		planet.LastShipCountUpdate += offset / 1e3
+	}
+	for _, report := range s.SpyReports {
+		report.CreatedAt += offset / 1e3
+		report.ValidUntil += offset / 1e3
+	}
+	for _, entry := range s.History {
+		entry.CreatedAt += offset
+	}
+	s.CreatedAt = now
+}
+
+// Export takes a snapshot of the universe and writes it as JSON.
+func Export(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(Take())
+}
+
+// Import reads a JSON snapshot and restores it, anchored to the current time.
+func Import(r io.Reader) error {
+	var s Snapshot
+
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&s); err != nil {
+		return err
+	}
+	return s.Restore(clock.Now())
+}
+
+// Fetches all entities of the world by the given pattern sorted by their
+// keys.
+func find(w *entities.World, pattern string) []entities.Entity {
+	result := w.Find(pattern)
+	sort.Sort(byKey(result))
+	return result
+}
+
+// Just a sorting interface of entities by their keys
+type byKey []entities.Entity
+
+func (b byKey) Len() int {
+	return len(b)
+}
+
+func (b byKey) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byKey) Less(i, j int) bool {
+	return b[i].Key() < b[j].Key()
+}
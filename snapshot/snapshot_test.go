@@ -0,0 +1,144 @@
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Vladimiroff/vec2d"
+
+	"warcluster/entities"
+	"warcluster/entities/db"
+)
+
+func flushDb() {
+	conn := db.Pool.Get()
+	defer conn.Close()
+
+	conn.Do("FLUSHDB")
+}
+
+func testSnapshot(createdAt int64) *Snapshot {
+	s := &Snapshot{
+		Version:   Version,
+		CreatedAt: createdAt,
+		Players: []*entities.Player{{
+			Username:       "gophie",
+			RaceID:         1,
+			TwitterID:      "gophie92",
+			HomePlanet:     "planet.GOP6720",
+			ScreenSize:     []uint64{1, 1},
+			ScreenPosition: vec2d.New(2, 2),
+		}},
+		Planets: []*entities.Planet{{
+			Name:                "GOP6720",
+			Position:            vec2d.New(2, 2),
+			IsHome:              true,
+			LastShipCountUpdate: createdAt/1e3 - 10,
+			ShipCount:           400,
+			Owner:               "gophie",
+		}},
+		Missions: []*entities.Mission{{
+			Type:       "Attack",
+			StartTime:  createdAt - 2000,
+			TravelTime: 5000,
+			Player:     "gophie",
+			ShipCount:  10,
+		}},
+		SpyReports: []*entities.SpyReport{{
+			Player:     "gophie",
+			Name:       "PAN6720",
+			CreatedAt:  createdAt/1e3 - 5,
+			ValidUntil: createdAt/1e3 + 25,
+		}},
+		History: []*entities.HistoryEntry{{
+			Player:    "gophie",
+			Event:     entities.SpiesCaught,
+			Planet:    "PAN6720",
+			Other:     "chochko",
+			Ships:     1,
+			CreatedAt: createdAt - 3000,
+		}},
+	}
+	s.Missions[0].Source.Name = "GOP6720"
+	s.Missions[0].Source.Position = vec2d.New(2, 2)
+	s.Missions[0].Target.Position = vec2d.New(200, 200)
+	return s
+}
+
+func TestReanchor(t *testing.T) {
+	s := testSnapshot(1352588400000)
+	s.reanchor(1352588460000)
+
+	if s.CreatedAt != 1352588460000 {
+		t.Errorf("CreatedAt is %d instead of 1352588460000", s.CreatedAt)
+	}
+	if s.Missions[0].StartTime != 1352588458000 {
+		t.Errorf("Mission's start time is %d instead of 1352588458000", s.Missions[0].StartTime)
+	}
+	if s.Planets[0].LastShipCountUpdate != 1352588450 {
+		t.Errorf("Planet's last update is %d instead of 1352588450", s.Planets[0].LastShipCountUpdate)
+	}
+	if s.SpyReports[0].ValidUntil != 1352588485 {
+		t.Errorf("Spy report is valid until %d instead of 1352588485", s.SpyReports[0].ValidUntil)
+	}
+	if s.History[0].CreatedAt != 1352588457000 {
+		t.Errorf("History entry is created at %d instead of 1352588457000", s.History[0].CreatedAt)
+	}
+}
+
+func TestRestoreUnsupportedVersion(t *testing.T) {
+	s := testSnapshot(1352588400000)
+	s.Version = Version + 1
+
+	if err := s.Restore(time.Now()); err == nil {
+		t.Error("Snapshot with unsupported version has been restored")
+	}
+}
+
+func TestRestoreIntoNonEmptyDatabase(t *testing.T) {
+	flushDb()
+	entities.Save(&entities.Planet{Name: "PAN6720", Position: vec2d.New(5, 5)})
+
+	if err := testSnapshot(time.Now().UnixNano() / 1e6).Restore(time.Now()); err == nil {
+		t.Error("Snapshot has been restored on top of another universe")
+	}
+	if players, _ := entities.GetList("player.*"); len(players) != 0 {
+		t.Errorf("%d players have been restored", len(players))
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	var buffer bytes.Buffer
+	flushDb()
+
+	s := testSnapshot(time.Now().UnixNano() / 1e6)
+	if err := s.Restore(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Export(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	flushDb()
+	if err := Import(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	imported := Take()
+	if len(imported.Players) != 1 || imported.Players[0].Username != "gophie" {
+		t.Errorf("Imported players are %#v", imported.Players)
+	}
+	if len(imported.Planets) != 1 || imported.Planets[0].ShipCount != 400 {
+		t.Errorf("Imported planets are %#v", imported.Planets)
+	}
+	if len(imported.Missions) != 1 {
+		t.Fatalf("Imported missions are %#v", imported.Missions)
+	}
+	if !entities.InArea(imported.Missions[0].Key(), "area:1:1") {
+		t.Error("Imported mission is not in its source area")
+	}
+	if len(imported.SpyReports) != 1 {
+		t.Errorf("Imported spy reports are %#v", imported.SpyReports)
+	}
+}